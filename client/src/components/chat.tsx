@@ -47,7 +47,12 @@ function getPlacerholder(state: Record<string, unknown>): string {
 }
 
 /**
- * Sends a message through the websocket
+ * Prefix of chat messages which are only sent to the team of the user
+ */
+const TEAM_CHAT_PREFIX = "/t ";
+
+/**
+ * Sends a message through the websocket, messages starting with the team chat prefix go to the team of the user
  *
  * @param state - the application global state record
  * @param event - A keyboard onKeyDown event
@@ -60,8 +65,15 @@ function sendMessage(state: Record<string, unknown>, event: KeyboardEvent): void
     // @ts-expect-error never null
     if (event.target.value.trim().length == 0) return; // eslint-disable-line
 
-    // @ts-expect-error state expected unknown
-    WS_CONNECTION?.sendMessage("sendMessage", state.user.getUsername(), state.user.getColor(), null, event.target.value); // eslint-disable-line
+    // @ts-expect-error never null
+    const message: string = event.target.value; // eslint-disable-line
+    if (message.startsWith(TEAM_CHAT_PREFIX)) {
+        // @ts-expect-error state expected unknown
+        WS_CONNECTION?.send("sendMessage", { Username: state.user.getUsername(), Color: state.user.getColor(), Message: message.slice(TEAM_CHAT_PREFIX.length), Team: true }); // eslint-disable-line
+    } else {
+        // @ts-expect-error state expected unknown
+        WS_CONNECTION?.sendMessage("sendMessage", state.user.getUsername(), state.user.getColor(), null, message); // eslint-disable-line
+    }
 
     // @ts-expect-error never null
     event.target.value = "";
@@ -3,6 +3,7 @@ import { jsxTransform, m_for, m_if_else, store, VElement } from "../../mist/inde
 import { SOUNDS } from "../modules/objects/sounds";
 import User from "../modules/objects/user";
 import { WS_CONNECTION } from "../modules/websocket/websocket";
+import { getPlayerState } from "../modules/websocket/state";
 import { toggleFx, toggleMusic } from "./sound-buttons";
 
 /**
@@ -51,7 +52,7 @@ const Players = (state: Record<string, unknown>): VElement => {
 };
 
 /**
- * Prepares the data to always contain 4 players
+ * Prepares the data to contain at least 4 players, games with more players show all of them
  *
  * @example
  * // if there are only 2 players, the last 2 spots will be filled with mock disabled users
@@ -61,10 +62,7 @@ const Players = (state: Record<string, unknown>): VElement => {
  */
 function prepareData(array: User[]): User[] {
 
-    for (const _ of array) { // eslint-disable-line
-        if (array.length == 4) {
-            return array;
-        }
+    while (array.length < 4) {
         array.push(new User("disabled", "disabled", "disabled"));
     }
 
@@ -85,13 +83,17 @@ const Player = (player: User): VElement => {
 
             m_if_else(player.getCharacter().getHealth() > 0,
                 <div class="stats-player">
-                    <div class="portrait-1 image" style={getColoredImage(player.getCharacter().getColor())}></div>
+                    <div class="portrait-1 image" style={getColoredImage(player.getCharacter().getColor()) + player.getCharacter().getTint()}></div>
 
                     <div class="stats-player-lives game-stats-text">
 
                         {player.getCharacter().getHealth()}
                     </div>
 
+                    <div class="stats-player-maxed game-stats-text">
+                        {maxedPowerups(player.getUserId())}
+                    </div>
+
                 </div>
                 ,
                 <div class="stats-player">
@@ -115,6 +117,19 @@ const Player = (player: User): VElement => {
     );
 };
 
+/**
+ * Lists the powerups the player has maxed out
+ *
+ * @param userId - id of the player
+ * @returns the names of the maxed powerups
+ */
+function maxedPowerups(userId: string): string {
+    const maxed = getPlayerState(userId)?.Powerups.Maxed ?? {};
+    const names = Object.keys(maxed).filter(name => maxed[name]);
+
+    return names.length ? `MAX ${names.join(" ")}` : "";
+}
+
 /**
  * Returns the user character portrait based on their character color
  *
@@ -122,7 +137,7 @@ const Player = (player: User): VElement => {
  * @returns the correct background-image
  */
 function getColoredImage(color: string): string {
-    return `background-image: url(src/assets/images/${color}/${color}_portrait.png)`;
+    return `background-image: url(src/assets/images/${color}/${color}_portrait.png);`;
 }
 
 /**
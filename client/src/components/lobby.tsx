@@ -6,6 +6,7 @@ import SoundButtons from "./sound-buttons";
 
 // Modules
 import User from "../modules/objects/user";
+import { LobbySettings } from "../modules/objects/lobby";
 import { WS_CONNECTION } from "../modules/websocket/websocket";
 import { SOUNDS } from "../modules/objects/sounds";
 
@@ -37,12 +38,15 @@ const Lobby = (state: Record<string, unknown>): VElement => {
 
                     {/* @ts-expect-error state expected unknown*/}
                     <button class="button font h3" style="padding-left: 25px; margin-bottom: 2px" onClick={(): void => { toggleReady(state); }}>{isReady(state.user, state) ? "Unready" : "Ready"}</button>
+                    {m_if(isTeamMode(state), (<button class="button font h3" style="padding-left: 25px; margin-bottom: 2px" onClick={(): void => switchTeam(state)}>Switch team</button>))}
                     <button class="button font h3" style="padding-left: 25px;" onClick={(): void => leaveLobby(state)}>Leave lobby</button>
 
                     {/* @ts-expect-error state expected unknown*/}
                     {m_if(state.activeLobby.getUsers().size == 1, (<div class="h3 font brightness" style="color: white; text-shadow: none; padding-bottom: 20px; position:absolute; top: 270px">Waiting for players...</div>))}
                 </div>
 
+                {renderSettings(state)}
+
                 <div class="lobby-players">
                     {/* @ts-expect-error state expected unknown*/}
                     {m_for(makeArray(state), (user: User) => { return renderUserCharacter(user, state); })}
@@ -98,6 +102,7 @@ function renderUserCharacter(user: User, state: Record<string, unknown>): VEleme
             <div class="h2 font brightness" style="color: white; text-shadow: none;">
                 {user.getUsername()}
             </div>
+            {m_if(user.getTeam() > 0, (<div class="h3 font brightness" style="color: white; text-shadow: none;">Team {user.getTeam()}</div>))}
             <div class={isReady(user, state) ? "ready-character image" : "unready-character image"} style={getStyle(user, isReady(user, state))}></div>
         </div>
     );
@@ -113,10 +118,10 @@ function renderUserCharacter(user: User, state: Record<string, unknown>): VEleme
  */
 function getStyle(user: User, ready: boolean): string {
     if (ready) {
-        return `background-image: url(src/assets/images/${user.getCharacter().getColor()}/win_${user.getCharacter().getColor()}.png)`;
+        return `background-image: url(src/assets/images/${user.getCharacter().getColor()}/win_${user.getCharacter().getColor()}.png); ${user.getCharacter().getTint()}`;
     }
     else {
-        return `background-image: url(src/assets/images/${user.getCharacter().getColor()}/lose_${user.getCharacter().getColor()}.png)`;
+        return `background-image: url(src/assets/images/${user.getCharacter().getColor()}/lose_${user.getCharacter().getColor()}.png); ${user.getCharacter().getTint()}`;
     }
 }
 
@@ -154,4 +159,134 @@ function toggleReady(state: Record<string, unknown>): void {
     SOUNDS?.playDing();
     // @ts-expect-error state expected unknown
     WS_CONNECTION?.sendMessage("userToggleReady", state.user.getUsername(), state.user.getColor(), state.activeLobby.getLobbyId(), null, state.activeLobby.getUserReadyState(state.user)); // eslint-disable-line 
-}
\ No newline at end of file
+}
+
+/**
+ * Fields of the lobby settings with their labels, powerup amounts are listed separately
+ */
+const SETTING_FIELDS: [keyof LobbySettings, string][] = [
+    ["Lives", "Lives"],
+    ["MaxPlayers", "Max players"],
+    ["Width", "Grid width"],
+    ["Height", "Grid height"],
+    ["FillPercentage", "Barrel fill"],
+    ["MatchDuration", "Match length (s)"],
+    ["ShrinkStart", "Shrink start (s)"],
+    ["Rounds", "Best of (rounds)"],
+];
+
+/**
+ * Lobby settings which are turned on or off, with their labels
+ */
+const TOGGLE_FIELDS: [keyof LobbySettings, string][] = [
+    ["TeamMode", "Teams"],
+    ["FriendlyFire", "Friendly fire"],
+];
+
+/**
+ * Timing profiles the lobby host can choose from, choosing one replaces the match length and shrink start
+ */
+const TIMING_PROFILES = ["blitz", "classic", "endurance"];
+
+/**
+ * Renders the lobby settings, the lobby host gets inputs to change them
+ *
+ * @param state - the application global state record
+ *
+ * @returns VElement
+ */
+function renderSettings(state: Record<string, unknown>): VElement {
+    // @ts-expect-error state expected unknown
+    const settings: LobbySettings | undefined = state.activeLobby.getSettings(); // eslint-disable-line 
+    // @ts-expect-error state expected unknown
+    const isHost: boolean = state.activeLobby.isHost(state.user); // eslint-disable-line 
+    if (!settings) {
+        return <div class="lobby-settings"></div>;
+    }
+
+    const fields: [string, string, number][] = SETTING_FIELDS.map(([key, label]) => [key, label, settings[key] as number]);
+    Object.keys(settings.Powerups).sort().forEach(name => fields.push([`Powerups.${name}`, name, settings.Powerups[name]]));
+    // the current profile is listed first so the select shows it
+    const profiles = [settings.Timing, ...TIMING_PROFILES.filter(name => name != settings.Timing)];
+
+    return (
+        <div class="lobby-settings flex-column">
+            <div class="lobby-setting font">
+                <span>Timing</span>
+                {isHost
+                    ? <select id="setting-Timing" class="setting-input font">{m_for(profiles, (name: string) => <option value={name}>{name}</option>)}</select>
+                    : <span>{settings.Timing}</span>}
+            </div>
+            {m_for(TOGGLE_FIELDS, ([key, label]: [keyof LobbySettings, string]) => (
+                <div class="lobby-setting font">
+                    <span>{label}</span>
+                    {isHost
+                        ? <select id={`setting-${key}`} class="setting-input font">{m_for(settings[key] ? ["on", "off"] : ["off", "on"], (value: string) => <option value={value}>{value}</option>)}</select>
+                        : <span>{settings[key] ? "on" : "off"}</span>}
+                </div>
+            ))}
+            {m_for(fields, ([key, label, value]: [string, string, number]) => (
+                <div class="lobby-setting font">
+                    <span>{label}</span>
+                    {isHost
+                        ? <input id={`setting-${key}`} class="setting-input font" type="number" step={key == "FillPercentage" ? "0.05" : "1"} value={value}></input>
+                        : <span>{value}</span>}
+                </div>
+            ))}
+            {m_if(isHost, (<button class="button font h3" style="padding-left: 25px;" onClick={(): void => updateSettings(state, fields)}>Save settings</button>))}
+        </div>
+    );
+}
+
+/**
+ * Sends the settings from the inputs through the websocket, only the lobby host can change them
+ *
+ * @param state - the application global state record
+ * @param fields - the rendered settings fields
+ */
+function updateSettings(state: Record<string, unknown>, fields: [string, string, number][]): void {
+    SOUNDS?.playDing();
+    const settings: Record<string, unknown> = {
+        Powerups: {},
+        Timing: (document.getElementById("setting-Timing") as HTMLSelectElement).value,
+    };
+    TOGGLE_FIELDS.forEach(([key]) => {
+        settings[key] = (document.getElementById(`setting-${key}`) as HTMLSelectElement).value == "on";
+    });
+
+    fields.forEach(([key]) => {
+        // @ts-expect-error never undefined
+        const value = Number(document.getElementById(`setting-${key}`).value);
+        if (key.startsWith("Powerups.")) {
+            (settings.Powerups as Record<string, number>)[key.slice("Powerups.".length)] = value;
+        } else {
+            settings[key] = value;
+        }
+    });
+
+    WS_CONNECTION?.send("updateLobbySettings", settings);
+}
+
+/**
+ * Returns whether the lobby splits its players into teams
+ *
+ * @param state - the application global state record
+ *
+ * @returns a boolean indicating whether team mode is on
+ */
+function isTeamMode(state: Record<string, unknown>): boolean {
+    // @ts-expect-error state expected unknown
+    return !!state.activeLobby.getSettings()?.TeamMode; // eslint-disable-line 
+}
+
+/**
+ * Sends a signal through the websocket to move the current user to the other team
+ *
+ * @param state - the application global state record
+ */
+function switchTeam(state: Record<string, unknown>): void {
+    SOUNDS?.playDing();
+    // @ts-expect-error state expected unknown
+    const team: number = state.user && state.activeLobby.getUsers().get(state.user.getUserId())?.getTeam(); // eslint-disable-line 
+    WS_CONNECTION?.send("switchTeam", { Team: team == 1 ? 2 : 1 });
+}
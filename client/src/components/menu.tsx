@@ -20,7 +20,7 @@ const Menu = (state: Record<string, unknown>): VElement => {
         <div class="menu-window">
             <div class="menu flex-column">
                 <div class="flex-column">
-                    <button class="button font h3" style="padding-left: 25px; margin-bottom: 20px" onClick={(): void => joinQuickPlay(state)}>Quick Play</button>
+                    <button class="button font h3" style="padding-left: 25px; margin-bottom: 20px" onClick={(): void => joinQuickPlay(state)}>{state.inQueue ? "Searching..." : "Quick Play"}</button>
                     <input id="code-input" placeholder="Lobby code" class="name-input font h3"></input>
                     <button class="button font h3" style="padding-left: 25px; margin-bottom: 2px" onClick={(): void => joinLobby(state)}>Join Lobby</button>
                     <button class="button font h3" style="padding-left: 25px;" onClick={(): void => createLobby(state)}>Create Lobby</button>
@@ -36,12 +36,16 @@ const Menu = (state: Record<string, unknown>): VElement => {
 export default Menu;
 
 /**
- * Sends a signal through the websocket to quick play and create or join an existing lobby
+ * Sends a signal through the websocket to join the matchmaking queue, or leave it if already searching for a match
  *
  * @param state - the application global state record
  */
 function joinQuickPlay(state: Record<string, unknown>): void {
     SOUNDS?.playDing();
+    if (state.inQueue) {
+        WS_CONNECTION?.send("leaveQueue");
+        return;
+    }
     // @ts-expect-error state expected unknown
     WS_CONNECTION?.sendMessage("quickPlay", state.user.getUsername(), state.user.getColor()); // eslint-disable-line 
 }
@@ -2,7 +2,7 @@
 import { jsxTransform, VElement } from "../../mist/index"; // eslint-disable-line 
 
 // Modules
-import { WS_CONNECTION } from "../modules/websocket/websocket";
+import { ACCOUNT_TOKEN_KEY, WS_CONNECTION } from "../modules/websocket/websocket";
 import { SOUNDS } from "../modules/objects/sounds";
 
 /**
@@ -42,7 +42,11 @@ function createUser(): void {
 
     SOUNDS?.playMusic();
 
-    WS_CONNECTION?.sendMessage("authenticate", username, getRandomColour());
+    WS_CONNECTION?.send("authenticate", {
+        Username: username,
+        Color: getRandomColour(),
+        AccountToken: localStorage.getItem(ACCOUNT_TOKEN_KEY) ?? "",
+    });
 }
 
 /**
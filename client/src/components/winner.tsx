@@ -1,5 +1,5 @@
 /** @jsx jsxTransform */
-import { jsxTransform, VElement } from "../../mist/index";
+import { jsxTransform, m_if, VElement } from "../../mist/index";
 
 /**
  * Represents the whole winner state
@@ -17,6 +17,7 @@ const Winner = (state: Record<string, unknown>): VElement => { // eslint-disable
                     <div class="h2 font brightness" style="color: white; text-shadow: none;">{gotWinner(state) ? state.winner.getUsername() : ""}</div>
                     <div class="ready-character image" style={getStyle(state)}></div>
                     <div class="winner font">{gotWinner(state) ? "Winner" : "Draw"}</div>
+                    {m_if(isSeries(state), (<div class="h3 font brightness" style="color: white; text-shadow: none;">{seriesText(state)}</div>))}
                 </div>
             </div>
         </div>
@@ -54,4 +55,36 @@ function gotWinner(state: Record<string, unknown>): boolean {
     else {
         return true;
     }
-}
\ No newline at end of file
+}
+
+/**
+ * Checks if the game is a round of a best-of series
+ *
+ * @param state - the application global state record
+ * @returns a bool whether the series has more than one round
+ */
+function isSeries(state: Record<string, unknown>): boolean {
+    // @ts-expect-error state expected unknown
+    return state.series?.Rounds > 1;
+}
+
+/**
+ * Returns the round wins of every player in the series, and the series winner once it is over
+ *
+ * @param state - the application global state record
+ * @returns text with the series standing
+ */
+function seriesText(state: Record<string, unknown>): string {
+    const series = state.series as { Round: number, Rounds: number, RoundWins: Record<string, number>, Over: boolean, Winners: { Username: string }[] | null };
+    const players = state.seriesPlayers as Record<string, { UserId: string, Username: string }>;
+
+    const wins = Object.values(players).map(player => `${player.Username} ${series.RoundWins[player.UserId] ?? 0}`).join(" - ");
+    if (!series.Over) {
+        return `Round ${series.Round} of ${series.Rounds}: ${wins}`;
+    }
+
+    if (!series.Winners?.length) {
+        return `Series drawn (${wins})`;
+    }
+    return `Series winner: ${series.Winners.map(winner => winner.Username).join(", ")} (${wins})`;
+}
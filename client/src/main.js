@@ -30,18 +30,25 @@ createStore({
     /* --------------------- USER --------------------- */
     
     user: null,
+    profile: null,
 
     /* --------------------- LOBBY --------------------- */
    
     activeLobby: placeHolderLobby,
+    inQueue: false,
     readyCounter: 0,
     gameReadyCounter: 0,
 
     /* --------------------- GAME --------------------- */
     
     activeGame: placeHolderGame,
+    spectating: false,
     gameCounter: 0,
     winner: placeHolderUser,
+    scoreboard: [],
+    series: null,
+    seriesPlayers: {},
+    replays: [],
 
     /* --------------------- SOUNDS  --------------------- */
     
@@ -54,6 +54,23 @@ export default class BombGrid {
         this.#getAnimation(bomb);
     }
 
+    /**
+     * Moves a bomb which was kicked or thrown to a new tile.
+     * @param from - coordinates the bomb was on.
+     * @param to - new coordinates.
+     */
+    moveBomb(from: Position, to: Position): void {
+        const bomb = this.#bombs.get(id(from, "bomb"));
+        if (!bomb) {
+            return;
+        }
+
+        this.#bombs.delete(id(from, "bomb"));
+        bomb.setPos(to);
+        this.#bombs.set(id(to, "bomb"), bomb);
+        force_update();
+    }
+
     /**
      * Starts and sets the animation on a selected bomb
      *
@@ -144,6 +161,14 @@ export class Bomb {
         this.#background = 1;
     }
 
+    /**
+     * Moves the bomb to new coordinates.
+     * @param pos - coordinates.
+     */
+    setPos(pos: Position): void {
+        this.#pos = pos;
+    }
+
     /**
      * Sets the bomb background to the corresponding number which then grid class can transorm into a tile
      * @param num - good number
@@ -1,6 +1,20 @@
 /** @jsx jsxTransform */
 import { jsxTransform, VElement } from "../../../mist/index"; // eslint-disable-line 
 
+/**
+ * Player colors with the sprite set their character uses, colors without a sprite set of their own tint another one by its hue
+ */
+export const PLAYER_COLORS: Record<string, { sprite: string, hue: number }> = {
+    "#D72C41": { sprite: "red", hue: 0 },
+    "#A864CC": { sprite: "purple", hue: 0 },
+    "#70C36D": { sprite: "green", hue: 0 },
+    "#4284EF": { sprite: "blue", hue: 0 },
+    "#E8C547": { sprite: "red", hue: 50 },
+    "#EE8434": { sprite: "red", hue: 25 },
+    "#3CC2C9": { sprite: "blue", hue: -35 },
+    "#E86AAE": { sprite: "purple", hue: 45 },
+};
+
 export class Character {
     #userId: string;
     #color: string | undefined;
@@ -11,6 +25,7 @@ export class Character {
 
     #animation: Animation | null;
     #currentDirection: string;
+    #curse: string;
 
     /**
      * Class representing the physical character in the playing field.
@@ -28,28 +43,25 @@ export class Character {
         this.#y = 50;
         this.#currentDirection = "down";
         this.#animation = null;
+        this.#curse = "";
     }
 
 
     /**
-     * Transform the characters hex color code into human readable color name and returns it
+     * Transform the characters hex color code into the name of its sprite set and returns it
      * @returns color
      */
     getColor(): string {
-        switch (this.#color) {
-        case "#D72C41":
-            return "red";
-        case "#A864CC":
-            return "purple";
-
-        case "#70C36D":
-            return "green";
-
-        case "#4284EF":
-            return "blue";
-        default:
-            return "";
-        }
+        return PLAYER_COLORS[this.#color ?? ""]?.sprite ?? "";
+    }
+
+    /**
+     * Gets the filter which tints the sprite set into the characters color.
+     * @returns filter for html inline style, empty for colors with their own sprite set.
+     */
+    getTint(): string {
+        const hue = PLAYER_COLORS[this.#color ?? ""]?.hue ?? 0;
+        return hue ? `filter: hue-rotate(${hue}deg);` : "";
     }
 
     /**
@@ -85,6 +97,9 @@ export class Character {
      * @returns class for html inline style.
      */
     #getClass(): string {
+        if (this.#health > 0 && this.#curse) {
+            return `player-character player-character-${this.getColor()} cursed cursed-${this.#curse}`;
+        }
         if (this.#health > 0) {
             return `player-character player-character-${this.getColor()}`;
         }
@@ -98,7 +113,7 @@ export class Character {
      * @returns html representation of the character.
      */
     getHTML(): VElement {
-        const pos = `left: ${this.#x}px; top: ${this.#y}px;`;
+        const pos = `left: ${this.#x}px; top: ${this.#y}px; ${this.getTint()}`;
 
         return (<div id={this.#userId} loading="eager" className={this.#getClass()} style={pos}></div>);
     }
@@ -128,6 +143,14 @@ export class Character {
         this.#y = y;
     }
 
+    /**
+     * Sets the skull curse of the player.
+     * @param curse - type of the curse, empty when the curse has ended.
+     */
+    setCurse(curse: string): void {
+        this.#curse = curse;
+    }
+
     /**
      * Updates the amount of lives the player has.
      * @param amount - amount of lives the player has.
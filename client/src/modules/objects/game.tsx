@@ -211,6 +211,28 @@ export default class Game {
         }
     }
 
+    /**
+     * Leaves every player with their last life when sudden death starts.
+     */
+    suddenDeath(): void {
+        this.#users.forEach(user => {
+            if (user.getCharacter().getHealth() > 1) {
+                user.getCharacter().loseLife(1);
+            }
+        });
+        force_update();
+    }
+
+    /**
+     * Shows or hides the skull curse of a player.
+     * @param userId - id of the cursed user.
+     * @param curse - type of the curse, empty when the curse has ended.
+     */
+    setCurse(userId: string, curse: string): void {
+        this.#users.get(userId)?.getCharacter().setCurse(curse);
+        force_update();
+    }
+
     /**
      * Places down a bomb to the grid.
      * @param user - The current user.
@@ -224,6 +246,15 @@ export default class Game {
         }
     }
 
+    /**
+     * Moves a kicked or thrown bomb on the grid.
+     * @param from - coordinates the bomb was on.
+     * @param to - new coordinates.
+     */
+    moveBomb(from: Position, to: Position): void {
+        this.#bombs?.moveBomb(from, to);
+    }
+
     /**
      * Creates an explosion effect on the grid and updates the grid afterwards.
      * @param userId - id of the user who placed the bomb.
@@ -231,7 +262,7 @@ export default class Game {
      * @param explosionArea - 2d array with coordinates of the explosion.
      * @param grid - grid update.
      */
-    explodeBomb(userId: string, pos: Position, explosionArea: Position[][], grid: number[][]): void {
+    explodeBomb(userId: string, pos: Position, explosionArea: Position[][], grid?: number[][]): void {
         const user = this.#users.get(userId);
         if (this.#bombs && user) {
             const timer = this.#bombs.explodeBomb(user.getColorName(), pos, explosionArea);
@@ -8,6 +8,34 @@ import PowerUp from "./powerUp";
 import { Position } from "./character";
 import { Bomb } from "./bomb";
 
+/**
+ * Power up types by the number of their tile, powerups defined on the server
+ * are added when a game starts.
+ */
+const POWERUP_TYPES = new Map<number, string>([
+    [7, "bomb"],
+    [8, "thunder"],
+    [9, "speed"],
+    [10, "kick"],
+    [11, "punch"],
+    [12, "remote"],
+    [13, "pierce"],
+    [14, "power"],
+    [15, "skull"],
+]);
+
+/**
+ * Adds the power ups of the game config to the known power up types, the
+ * type of a new power up is its lowercase name.
+ * @param powerups - power ups of the game config by name.
+ */
+export function setPowerupTypes(powerups: Record<string, { Name: string, Icon: number }>): void {
+    Object.values(powerups).forEach(powerup => {
+        if (powerup.Icon > 6 && !POWERUP_TYPES.has(powerup.Icon)) {
+            POWERUP_TYPES.set(powerup.Icon, powerup.Name.toLowerCase());
+        }
+    });
+}
 
 export default class Grid {
     #tileSize: number;
@@ -159,12 +187,11 @@ export default class Grid {
                     arr.push(2);
                 }
                 else if (obj instanceof PowerUp) {
-                    switch (obj.getType()) {
-                    case "bomb": arr.push(7); break;
-                    case "thunder": arr.push(8); break;
-                    case "speed": arr.push(9); break;
+                    for (const [tile, type] of POWERUP_TYPES) {
+                        if (type === obj.getType()) {
+                            arr.push(tile);
+                        }
                     }
-
                 }
                 else {
                     throw Error("there isn't number assigned to this object");
@@ -185,6 +212,13 @@ export default class Grid {
      * 7 - power up: bomb
      * 8 - power up: thunder
      * 9 - power up: speed
+     * 10 - power up: kick
+     * 11 - power up: punch
+     * 12 - power up: remote bomb
+     * 13 - power up: pierce bomb
+     * 14 - power up: power bomb
+     * 15 - power up: skull
+     * other power ups defined on the server follow
      * @param layout - new layout of the grid.
      */
     update(layout: number[][] = this.getLayout()): void {
@@ -219,16 +253,9 @@ export default class Grid {
                     break;
                 }
 
-                case (tile > 6 && tile < 10): {
+                case (POWERUP_TYPES.has(tile)): {
                     if (!(this.#layout[col][row] instanceof PowerUp)) {
-                        let type;
-                        switch (tile) {
-                        case 7: type = "bomb"; break;
-                        case 8: type = "thunder"; break;
-                        case 9: type = "speed"; break;
-                        default: type = "ERROR";
-                        }
-                        this.#layout[col][row] = new PowerUp({ X: row, Y: col }, type);
+                        this.#layout[col][row] = new PowerUp({ X: row, Y: col }, POWERUP_TYPES.get(tile) as string);
                     }
                     break;
                 }
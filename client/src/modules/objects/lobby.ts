@@ -5,18 +5,42 @@ import User from "./user";
 import { startUserCounter, stopUserCounter } from "../websocket/responses";
 import { store } from "../../../mist";
 
+/**
+ * Game settings of the lobby which the lobby host can change
+ */
+export interface LobbySettings {
+    Lives: number;
+    MaxPlayers: number;
+    Width: number;
+    Height: number;
+    FillPercentage: number;
+    Powerups: Record<string, number>;
+    Timing: string;
+    MatchDuration: number;
+    ShrinkStart: number;
+    Rounds: number;
+    TeamMode: boolean;
+    FriendlyFire: boolean;
+}
+
 export default class Lobby {
     #lobbyId: string;
     #users: Map<string, User>;
+    #hostId: string;
+    #settings: LobbySettings | undefined;
 
     /**
      * Class representing the current active lobby.
      * 
      * @param lobbyId lobby id
      * @param users the users that are part of the lobby
+     * @param hostId id of the user who can change the lobby settings
+     * @param settings game settings of the lobby
      */
-    constructor(lobbyId: string, users: User[]) {
+    constructor(lobbyId: string, users: User[], hostId = "", settings?: LobbySettings) {
         this.#lobbyId = lobbyId
+        this.#hostId = hostId
+        this.#settings = settings
         this.#users = new Map<string, User>()
         users.forEach(user => {
             this.addUser(user)
@@ -41,6 +65,26 @@ export default class Lobby {
         return this.#users
     }
 
+    /**
+    *  Returns the game settings of the lobby
+    * 
+    *  @return Returns settings {LobbySettings | undefined}
+    */
+    getSettings(): LobbySettings | undefined {
+        return this.#settings
+    }
+
+    /**
+     *  Returns whether the user is the host of the lobby
+     * 
+     *  @param user the user to check
+     * 
+     *  @return Returns a boolean indicating whether the user can change the lobby settings
+     */
+    isHost(user: User): boolean {
+        return !!user && this.#hostId !== "" && user.getUserId() == this.#hostId
+    }
+
     /**
      *  Returns the wanted users ready status in the lobby
      * 
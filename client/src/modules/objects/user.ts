@@ -1,6 +1,6 @@
 // Modules
 import { VElement } from "../../../mist";
-import { Character, Position } from "./character";
+import { Character, PLAYER_COLORS, Position } from "./character";
 import Grid from "./grid";
 
 export default class User {
@@ -10,20 +10,23 @@ export default class User {
     #character: Character;
     #readyState: boolean;
     #joinDate: string;
+    #team: number;
 
     /**
      * Class representing the setting of the current user.
      *
      * @param username - name of the user
      * @param color - color picked by the user. Need to be 3 rgb values
+     * @param team - team of the user in a team mode lobby, 0 without teams
      */
-    constructor(username: string, color: string, userId: string, readyState = false, joinDate?: string) {
+    constructor(username: string, color: string, userId: string, readyState = false, joinDate?: string, team = 0) {
         this.#color = color;
         this.#username = username;
         this.#userId = userId;
         this.#character = new Character(userId, color);
         this.#readyState = readyState;
         this.#joinDate = joinDate || "";
+        this.#team = team;
     }
 
     /* ----------------------- GETTERS ----------------------- */
@@ -53,17 +56,11 @@ export default class User {
     }
     
     /**
-     * Gets the user color in the general color name.
+     * Gets the name of the sprite set of the users color.
      * @return name of color.
      */
     getColorName(): string {
-        switch (this.#color) {
-            case "#D72C41": return "red"
-            case "#70C36D": return "green"
-            case "#A864CC": return "purple"
-            case "#4284EF": return "blue"
-        }
-        return "COLOR ERROR"
+        return PLAYER_COLORS[this.#color]?.sprite ?? "COLOR ERROR"
     }
 
     /**
@@ -90,6 +87,14 @@ export default class User {
         return this.#readyState;
     }
 
+    /**
+     * Gets the team of the user in a team mode lobby.
+     * @returns team number, 0 without teams.
+     */
+    getTeam(): number {
+        return this.#team;
+    }
+
     /* ----------------------- SETTERS ----------------------- */
 
 
@@ -8,39 +8,51 @@ import { WS_CONNECTION } from "./websocket";
 let placeBomb = false;
 
 /**
- * Object that stores the directions the user is moving and how many frames have
- * passed since the last character movement.
+ * The direction the user last moved to, bombs are kicked and thrown to it.
+ */
+let facing = "down";
+
+/**
+ * Milliseconds between movement steps, the server drops steps sent faster than
+ * 30 per second.
+ */
+const MOVE_INTERVAL = 1000 / 30;
+
+/**
+ * Object that stores the directions the user is moving and when the character
+ * was last moved.
  */
 let movement = {
   left: false,
   right: false,
   up: false,
   down: false,
-  frames: 0,
+  lastMove: 0,
 }
 
 /**
  * Callback function for request animation frame. Moves the player as long as the 
  * state is "game".
+ *
+ * @param time - the time of the animation frame
  */
-export function move(): void {
-  if (movement.frames === 1) {
-    movement.frames = 0;
+export function move(time: DOMHighResTimeStamp): void {
+  // spectators can't move
+  if (!store.spectating && time - movement.lastMove >= MOVE_INTERVAL) {
+    movement.lastMove = time;
 
     if (movement.up && !movement.down) {
-      WS_CONNECTION?.sendMessage("move", "", "", "", "up");
+      WS_CONNECTION?.send("move", { Direction: "up" });
     }
     if (movement.down && !movement.up) {
-      WS_CONNECTION?.sendMessage("move", "", "", "", "down");
+      WS_CONNECTION?.send("move", { Direction: "down" });
     }
     if (movement.left && !movement.right) {
-      WS_CONNECTION?.sendMessage("move", "", "", "", "left");
+      WS_CONNECTION?.send("move", { Direction: "left" });
     }
     if (movement.right && !movement.left) {
-      WS_CONNECTION?.sendMessage("move", "", "", "", "right");
+      WS_CONNECTION?.send("move", { Direction: "right" });
     }
-  } else {
-    movement.frames++;
   }
 
   if (store.gameState === "game") {
@@ -49,8 +61,8 @@ export function move(): void {
 }
 
 /**
- * Sets movement to left, right, up or down or places down a bomb. 
- * Valid inputs are arrow keys and ASDW.
+ * Sets movement to left, right, up or down, places down a bomb or kicks (E) and
+ * throws (Q) a bomb and detonates remote bombs (R). Valid inputs are arrow keys and ASDW.
  */
 document.body.addEventListener("keydown", (e) => {
   const input = document.getElementById("chat-input")
@@ -61,29 +73,51 @@ document.body.addEventListener("keydown", (e) => {
       case "ArrowUp":
       case "KeyW": {
         movement.up = true;
+        facing = "up";
         break
       }
       case "ArrowDown":
       case "KeyS": {
         movement.down = true;
+        facing = "down";
         break
       }
       case "ArrowLeft":
       case "KeyA": {
         movement.left = true;
+        facing = "left";
         break
       }
       case "ArrowRight":
       case "KeyD": {
         movement.right = true;
+        facing = "right";
+        break
+      }
+      case "KeyE": {
+        if (!store.spectating) {
+          WS_CONNECTION?.send("kickBomb", { Direction: facing });
+        }
+        break
+      }
+      case "KeyR": {
+        if (!store.spectating) {
+          WS_CONNECTION?.send("detonate");
+        }
+        break
+      }
+      case "KeyQ": {
+        if (!store.spectating) {
+          WS_CONNECTION?.send("throwBomb", { Direction: facing });
+        }
         break
       }
       case "Space": {
-        if (!placeBomb) {
+        if (!placeBomb && !store.spectating) {
           placeBomb = true;
           /* @ts-expect-error */
           // store.activeGame.placeBomb(store.user.getUserId(), {x: 1, y: 1});
-          WS_CONNECTION?.sendMessage("bombPlaced", store.user.getUserId());
+          WS_CONNECTION?.send("bombPlaced");
         }
         break
 
@@ -107,7 +141,7 @@ document.body.addEventListener("keyup", (e) => {
       case "KeyW": {
         movement.up = false;
         if (noMovement()) {
-          WS_CONNECTION?.sendMessage("move", "", "", "", "stop");
+          WS_CONNECTION?.send("move", { Direction: "stop" });
         }
         break
       }
@@ -115,7 +149,7 @@ document.body.addEventListener("keyup", (e) => {
       case "KeyS": {
         movement.down = false;
         if (noMovement()) {
-          WS_CONNECTION?.sendMessage("move", "", "", "", "stop");
+          WS_CONNECTION?.send("move", { Direction: "stop" });
         }
         break
       }
@@ -123,7 +157,7 @@ document.body.addEventListener("keyup", (e) => {
       case "KeyA": {
         movement.left = false;
         if (noMovement()) {
-          WS_CONNECTION?.sendMessage("move", "", "", "", "stop");
+          WS_CONNECTION?.send("move", { Direction: "stop" });
         }
         break
       }
@@ -131,7 +165,7 @@ document.body.addEventListener("keyup", (e) => {
       case "KeyD": {
         movement.right = false;
         if (noMovement()) {
-          WS_CONNECTION?.sendMessage("move", "", "", "", "stop");
+          WS_CONNECTION?.send("move", { Direction: "stop" });
         }
         break
       }
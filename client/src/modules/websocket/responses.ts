@@ -19,7 +19,7 @@ export function joinLobby(data: Record<string, unknown>): void {
 
     //@ts-expect-error
     for (const user of data.Users) {
-        const tempU = new User(user.Username, user.Color, user.UserId, user.ReadyState, user.Time)
+        const tempU = new User(user.Username, user.Color, user.UserId, user.ReadyState, user.Time, user.Team)
 
         //@ts-expect-error
         if (tempU.getUserId() == store.user.getUserId()) {
@@ -31,7 +31,7 @@ export function joinLobby(data: Record<string, unknown>): void {
     }
 
     //@ts-expect-error
-    store.activeLobby = new Lobby(data.GameId, users)
+    store.activeLobby = new Lobby(data.GameId, users, data.HostId, data.Settings)
 
     store.gameState = "lobby"
     force_update()
@@ -70,7 +70,7 @@ export function sendMessage(data: Record<string, unknown>): void {
     //@ts-expect-error
     store.messages.unshift({
         system: false,
-        sender: data.Username,
+        sender: data.Spectator ? `[spectator] ${data.Username}` : data.Team ? `[team] ${data.Username}` : data.Username,
         content: data.Message,
         color: data.Color,
         date: data.Date,
@@ -0,0 +1,125 @@
+import { Position } from "../objects/character";
+
+/**
+ * Player fields that are synced from the server on every game tick
+ */
+export interface PlayerState {
+    UserId: string;
+    Position: Position;
+    Lives: number;
+    Powerups: Record<string, number | string> & { Maxed?: Record<string, boolean> };
+}
+
+/**
+ * Game state on one server tick
+ */
+export interface GameState {
+    tick: number;
+    grid: number[][];
+    players: Map<string, PlayerState>;
+    /** players of the previous state who have left the game */
+    removed: string[];
+}
+
+/**
+ * Changes sent by the server on every game tick
+ */
+export interface StateDelta {
+    Tick: number;
+    BaseTick: number;
+    Keyframe: boolean;
+    Tiles: { X: number, Y: number, Value: number }[];
+    Players: Partial<PlayerState>[];
+    Removed: string[] | null;
+    Events: { Type: string, Payload: Record<string, unknown> }[] | null;
+}
+
+/**
+ * Holds the states received from the server that can still be used as a base for the next deltas
+ */
+const states = new Map<number, GameState>();
+
+/**
+ * Holds the latest state received from the server
+ */
+let currentState: GameState | null = null;
+
+/**
+ * Applies a delta received from the server on top of the state it was based on
+ *
+ * @param delta - the delta received from the server
+ * @returns the new state or null if the base state is not known and a keyframe is needed
+ */
+export function applyDelta(delta: StateDelta): GameState | null {
+    const base = delta.Keyframe ? null : states.get(delta.BaseTick);
+    if (!delta.Keyframe && !base) {
+        return null;
+    }
+
+    const state: GameState = {
+        tick: delta.Tick,
+        grid: base ? base.grid.map(row => [...row]) : [],
+        players: new Map(),
+        removed: [],
+    };
+    base?.players.forEach((player, userId) => state.players.set(userId, { ...player }));
+
+    delta.Tiles.forEach(tile => {
+        while (state.grid.length <= tile.Y) {
+            state.grid.push([]);
+        }
+        state.grid[tile.Y][tile.X] = tile.Value;
+    });
+
+    delta.Players.forEach(player => {
+        const userId = player.UserId as string;
+        state.players.set(userId, { ...state.players.get(userId), ...player } as PlayerState);
+    });
+
+    delta.Removed?.forEach(userId => state.players.delete(userId));
+    // keyframes don't list removed players, they are the players missing from the keyframe
+    const previous = delta.Keyframe ? currentState : base;
+    previous?.players.forEach((_, userId) => {
+        if (!state.players.has(userId)) {
+            state.removed.push(userId);
+        }
+    });
+
+    // older states than the base will not be used by the server anymore
+    for (const tick of states.keys()) {
+        if (tick < (delta.Keyframe ? delta.Tick : delta.BaseTick)) {
+            states.delete(tick);
+        }
+    }
+    states.set(state.tick, state);
+    currentState = state;
+
+    return state;
+}
+
+/**
+ * Clears all saved states, used when a new game starts
+ */
+export function clearStates(): void {
+    states.clear();
+    currentState = null;
+}
+
+/**
+ * Returns the synced state of a player in the latest state
+ *
+ * @param userId - id of the player
+ * @returns the player state or undefined if the player is not in the game
+ */
+export function getPlayerState(userId: string): PlayerState | undefined {
+    return currentState?.players.get(userId);
+}
+
+/**
+ * Returns the grid of the latest state
+ *
+ * @returns 2D number array with the game board
+ */
+export function getGrid(): number[][] | undefined {
+    return currentState?.grid;
+}
@@ -5,35 +5,142 @@ import { stopGameCounter } from "../../components/gameStats";
 import User from "../objects/user";
 import { joinLobby, sendMessage, sendSystem, startUserCounter, startGameReadyCounter, stopUserCounter, stopGameReadyCounter, startGame } from "./responses";
 import Game from "../objects/game"
+import Lobby from "../objects/lobby";
+import { setPowerupTypes } from "../objects/grid";
 import { move } from "./movement";
 import { SOUNDS } from "../objects/sounds";
+import { applyDelta, clearStates, getGrid } from "./state";
+import { decode as decodeMsgpack } from "@msgpack/msgpack";
+import { decode as decodeCbor } from "cbor-x";
+
+/**
+ * Version of the websocket protocol, has to match the servers version
+ */
+export const PROTOCOL_VERSION = 2;
+
+/**
+ * Key of the session token in the session storage, the token is used to resume the session after the connection drops
+ */
+const SESSION_TOKEN_KEY = "sessionToken";
+
+/**
+ * Key of the account token in the local storage, the token logs the user back in to their profile
+ */
+export const ACCOUNT_TOKEN_KEY = "accountToken";
+
+/**
+ * Milliseconds to wait before reconnecting a dropped connection
+ */
+const RECONNECT_DELAY = 1000;
+
+/**
+ * Subprotocols the client can decode, in order of preference. The server answers with the first one it supports,
+ * the messages sent to the server are always JSON
+ */
+export const SUBPROTOCOLS = ["bomberman.msgpack", "bomberman.cbor", "bomberman.json"];
+
+/**
+ * Decodes a message from the server with the subprotocol of the connection, text messages are JSON
+ *
+ * @param data - the data of the message event
+ * @param protocol - the subprotocol the server chose
+ */
+export const decodeMessage = (data: string | ArrayBuffer, protocol: string): any => {
+  if (typeof data === "string") {
+    return JSON.parse(data);
+  }
+
+  switch (protocol) {
+    case "bomberman.msgpack":
+      return decodeMsgpack(data);
+    case "bomberman.cbor":
+      return decodeCbor(new Uint8Array(data));
+  }
+  return JSON.parse(new TextDecoder().decode(data));
+};
 
 export class WSConnection {
-  connection = new WebSocket(`ws://localhost:${import.meta.env.VITE_BACKEND_PORT}/websocket`);
+  connection = new WebSocket(`ws://localhost:${import.meta.env.VITE_BACKEND_PORT}/websocket`, SUBPROTOCOLS);
+  rejected = false;
 
   /**
    * Represents a Web Socket connection to a socket server
    * 
    */
   constructor() {
+    // binary subprotocols are decoded from the raw bytes of the message
+    this.connection.binaryType = "arraybuffer";
+    this.connection.onopen = () => this.send("hello", { SessionToken: sessionStorage.getItem(SESSION_TOKEN_KEY) ?? "" });
     this.connection.onmessage = this.onMessage;
+    this.connection.onclose = this.onClose;
   }
 
+  /**
+   * Reconnects after the connection has dropped, the session is resumed with the saved session token
+   */
+  onClose = (): void => {
+    if (this.rejected) {
+      return;
+    }
+    setTimeout(connectWs, RECONNECT_DELAY);
+  };
+
   /**
    * Listens to Web Socket MessageEvents
    * 
    * @param ev the event that comes through the socket
    */
   onMessage = async (ev: MessageEvent): Promise<void> => {
-    let data = JSON.parse(ev.data);
+    this.handle(decodeMessage(ev.data, this.connection.protocol));
+  };
 
+  /**
+   * Handles a single message envelope from the server
+   * 
+   * @param envelope the parsed message envelope
+   */
+  handle = (envelope: any): void => {
+    const data = { ...envelope.Payload, Type: envelope.Type };
 
     switch (data.Type) {
+      /* ------------------------- PROTOCOL -------------------------*/
+      case "protocolError":
+        this.rejected = true;
+        alert(data.Message);
+        break;
+
+      case "kicked":
+        this.rejected = true;
+        sessionStorage.removeItem(SESSION_TOKEN_KEY);
+        alert(data.Reason);
+        break;
+
+      case "welcome":
+        // the session expired while the connection was down, the user has to log in again
+        if (!data.Resumed && store.user) {
+          sessionStorage.removeItem(SESSION_TOKEN_KEY);
+          store.user = null;
+          store.gameState = "pre-menu";
+          force_update();
+        }
+        break;
+
+      case "messageError":
+        console.warn(`Server rejected '${data.Rejected}' message: ${data.Message}`);
+        break;
+
       /* ------------------------- USER -------------------------*/
       case "createUser":
         const user = new User(data.Username, data.Color, data.UserId)
         store.user = user
         store.gameState = "menu"
+        sessionStorage.setItem(SESSION_TOKEN_KEY, data.SessionToken)
+        localStorage.setItem(ACCOUNT_TOKEN_KEY, data.AccountToken)
+        break;
+
+      case "profile":
+        store.profile = data
+        force_update()
         break;
 
       /* ------------------------- CHAT -------------------------*/
@@ -47,15 +154,28 @@ export class WSConnection {
 
       /* ------------------------- LOBBY -------------------------*/
       case "joinLobby":
+        store.spectating = false
+        store.inQueue = false
         joinLobby(data)
         startUserCounter()
         break;
 
+      case "queueJoined":
+        store.inQueue = true
+        force_update()
+        break;
+
+      case "queueLeft":
+        store.inQueue = false
+        force_update()
+        break;
+
       case "lobbyError":
         alert(data.Message);
         break;
 
       case "leaveLobby":
+        store.spectating = false;
         //@ts-expect-error
         store.activeLobby = store.activeLobby.leaveLobby();
         store.gameState = "menu";
@@ -79,6 +199,23 @@ export class WSConnection {
         force_update()
         break;
 
+      case "lobbySettings":
+        joinLobby(data)
+        sendSystem(data)
+        // everyone has to get ready again with the new settings
+        stopGameReadyCounter()
+        stopUserCounter()
+        startUserCounter()
+        force_update()
+        break;
+
+      case "userSwitchTeam":
+        joinLobby(data)
+        sendSystem(data)
+        stopGameReadyCounter()
+        force_update()
+        break;
+
       case "userToggleReady":
         const currentU = new User(data.Username, data.Color, data.UserId)
         //@ts-expect-error
@@ -96,7 +233,20 @@ export class WSConnection {
         break
 
       /* ------------------------- GAME -------------------------*/
+      case "spectate":
+        store.spectating = true
+        // spectators who joined a running game have no lobby yet
+        if (!data.Eliminated) {
+          store.activeLobby = new Lobby(data.GameId, [])
+        }
+        force_update()
+        break
+
       case "startGame":
+        // players eliminated in the last round of a series play again
+        store.spectating = !store.user || !data.GameInfo.Players[store.user.getUserId()]
+        clearStates()
+        setPowerupTypes(data.GameInfo.Config.Powerups)
         startGame(data)
         requestAnimationFrame(move)
         break
@@ -106,6 +256,7 @@ export class WSConnection {
         break
 
       case "leaveGame":
+        store.spectating = false;
         store.gameState = "menu";
         store.activeGame = new Game()
         if (SOUNDS?.getMusicStatus()) {
@@ -115,21 +266,30 @@ export class WSConnection {
         force_update()
         break
 
-      case "shrinkMap":
+      case "gameState": {
+        const state = applyDelta(data)
+        // without the base state only a keyframe can be applied, acknowledging 0 asks the server for one
+        this.sendAck(state ? state.tick : 0)
+        // players who have left the game aren't drawn anymore
         /* @ts-expect-error */
-        store.activeGame.update(data.GameInfo.Grid);
+        state?.removed.forEach(userId => store.activeGame.leave(userId))
+        data.Events?.forEach(this.handle)
+        break
+      }
+
+      case "shrinkMap":
         /* @ts-expect-error */
-        store.activeGame.move(data.UserId, data.Position, data.Message);
+        store.activeGame.update(getGrid());
         break
 
       case "move":
         /* @ts-expect-error */
-        store.activeGame.move(data.UserId, data.Position, data.Message);
+        store.activeGame.move(data.UserId, data.Position, data.Direction);
         break
 
       case "loseLife":
         /* @ts-expect-error */
-        store.activeGame.loseLife(data.UserId, data.GameInfo.Players[data.UserId].Lives)
+        store.activeGame.loseLife(data.UserId, data.Lives)
         break
 
       case "bombPlaced":
@@ -137,23 +297,59 @@ export class WSConnection {
         store.activeGame.placeBomb(data.UserId, data.Bomb.Position);
         break
 
+      case "suddenDeath":
+        sendSystem(data)
+        if (data.OneLife) {
+          /* @ts-expect-error */
+          store.activeGame.suddenDeath()
+        }
+        break
+
+      case "curse":
+        /* @ts-expect-error */
+        store.activeGame.setCurse(data.UserId, data.Curse)
+        break
+
+      case "bombMoved":
+        /* @ts-expect-error */
+        store.activeGame.moveBomb(data.From, data.Bomb.Position)
+        break
+
       case "bombExploded":
         /* @ts-expect-error */
-        store.activeGame.explodeBomb(data.Bomb.UserId, data.Bomb.Position, data.Bomb.ExplosionArea, data.GameInfo.Grid)
+        store.activeGame.explodeBomb(data.Bomb.UserId, data.Bomb.Position, data.Bomb.ExplosionArea)
+        break
+
+      case "scoreboard":
+        store.scoreboard = data.Players
+        force_update()
         break
 
       case "updateGrid":
+      case "powerupsDropped":
+      case "powerupsDestroyed":
         /* @ts-expect-error */
-        store.activeGame.update(data.GameInfo.Grid)
+        store.activeGame.update(getGrid())
+        break
+      /* ------------------------- REPLAYS -------------------------*/
+      case "replays":
+        store.replays = data.Replays
+        force_update()
         break
+
       case "gameOver":
         stopGameCounter()
 
         store.gameState = "winner"
+        store.scoreboard = data.Scoreboard
+        store.series = data.Series
+        store.seriesPlayers = data.GameInfo.Players
 
-        if (data.Message == "win") {
+        if (data.Result == "win" && data.Team) {
+          store.winner = new User(`Team ${data.Team}`, data.Winners[0].Color, data.Winners[0].UserId)
+        } else if (data.Result == "win") {
 
-          store.winner = new User(data.Users[0].Username, data.Users[0].Color, data.Users[0].userId)
+          store.winner = new User(data.Winners[0].Username, data.Winners[0].Color, data.Winners[0].UserId)
 
         } else {
           store.winner = "draw"
@@ -182,17 +378,43 @@ export class WSConnection {
     message?: string,
     readyState?: boolean,
   ): void => {
+    this.send(type, {
+      Username: username,
+      Color: color,
+      GameId: gameId,
+      Message: message,
+      ReadyStatus: readyState,
+    });
+  };
+
+  /**
+   * Sends a message wrapped in a protocol envelope through the socket
+   * 
+   * @param type - the type of socket message
+   * @param payload - the fields of the message
+   */
+  send = (type: string, payload: Record<string, unknown> = {}): void => {
     this.connection.send(
       JSON.stringify({
+        Version: PROTOCOL_VERSION,
         Type: type,
-        Username: username,
-        Color: color,
-        GameId: gameId,
-        Message: message,
-        ReadyStatus: readyState,
+        Payload: payload,
       })
     );
   };
+
+  /**
+   * Acknowledges the last game state received, the server sends the next states as changes to it
+   * 
+   * @param tick - the tick of the state
+   */
+  sendAck = (tick: number): void => {
+    this.send("ack", {
+      Tick: tick,
+      //@ts-expect-error
+      GameId: store.activeLobby?.getLobbyId(),
+    });
+  };
 }
 
 /**
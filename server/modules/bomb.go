@@ -1,7 +1,7 @@
 package modules
 
 import (
-	"sync"
+	"math"
 	"time"
 )
 
@@ -10,12 +10,27 @@ const (
 	Explosion
 )
 
-// Game contains game data
+const (
+	bombFuseTime      = 3 * time.Second
+	explosionTime     = 1 * time.Second
+	barrelBreakTime   = 1300 * time.Millisecond
+	gridUpdateTime    = 1500 * time.Millisecond
+	invincibilityTime = 3 * time.Second
+)
 
-// BombPlaced Places bomb on grid, activates BombExploded function func after 3 seconds 
-func BombPlaced(data Data) {
-	user := GlobalClients.GetUser(UserId(data.UserId))
-	if user.Powerups.Bombs <= 0 {
+// BombType changes how a bomb is detonated and how its explosion spreads
+type BombType string
+
+const (
+	NormalBomb BombType = ""
+	RemoteBomb BombType = "remote" // Has no fuse, explodes when the owner sends a detonate message
+	PierceBomb BombType = "pierce" // The explosion passes through barrels
+	PowerBomb  BombType = "power"  // The explosion has the maximum range, a player can only have one power bomb at a time
+)
+
+// BombPlaced Places bomb on grid, the bomb explodes on the game tick bombFuseTime later
+func BombPlaced(user *User) {
+	if user.Powerups.Bombs <= 0 || user.Curse.Type == NoBombs {
 		return
 	}
 	game := GlobalGames.GetGame(GameId(user.GameId))
@@ -23,74 +38,225 @@ func BombPlaced(data Data) {
 	characterCenter := game.Config.CharacterSize / 2
 	var currentTile = game.CurrentTileOnGrid(AbsolutePosition{X: user.Position.X + characterCenter, Y: user.Position.Y + characterCenter})
 
-	data.Bomb.UserId = UserId(data.UserId)
-	data.Bomb.Position.X = currentTile.X
-	data.Bomb.Position.Y = currentTile.Y
+	// only one bomb fits on a tile
+	if _, ok := game.bombTiles[Position(currentTile)]; ok {
+		return
+	}
 
 	user.Powerups.Bombs -= 1
+	user.Stats.BombsPlaced++
+
+	bomb := Bomb{
+		Position:     Position(currentTile),
+		UserId:       user.UserId,
+		Type:         game.NextBombType(user),
+		DetonateTick: game.Tick + game.Ticks(bombFuseTime),
+	}
+	if bomb.Type == RemoteBomb {
+		bomb.DetonateTick = 0
+	}
+	game.bombs = append(game.bombs, &bomb)
+	game.SetBombTile(&bomb, bomb.Position)
+
+	game.Emit(BombPlacedEvent{
+		UserId: user.UserId,
+		Bomb:   bomb,
+	})
+}
 
-	bomb := data.Bomb
-	bomb.UserId = UserId(data.UserId)
+// UpdateBombs explodes all bombs whose fuse has run out on the current tick or which are in an active explosion.
+// Explosions detonate the bombs they reach, the whole chain reaction explodes on the same tick.
+func (game *Game) UpdateBombs() {
+	game.UpdateMovingBombs()
 
-	sendData := Data{
-		Type:     "bombPlaced",
-		UserId:   data.UserId,
-		GameInfo: game.PrepareForSend(),
-		Bomb:     data.Bomb,
+	var detonating []*Bomb
+	for _, bomb := range game.bombs {
+		// thrown bombs explode once they land
+		if bomb.LandTick > game.Tick {
+			continue
+		}
+		// remote bombs of eliminated players and of players who have left the game explode right away
+		if owner := game.Player(bomb.UserId); bomb.Type == RemoteBomb && bomb.DetonateTick == 0 && (owner == nil || owner.Lives <= 0) {
+			bomb.DetonateTick = game.Tick
+		}
+		if bomb.FuseRunOut(game.Tick) || game.ActiveExplosions[bomb.Position.Y][bomb.Position.X] == Explosion {
+			detonating = append(detonating, bomb)
+		}
 	}
-	err := GlobalGames.BroadcastToGame(game.GameId, sendData)
-	HandleError(err)
 
-	go func() {
-		time.Sleep(3 * time.Second)
+	for len(detonating) != 0 {
+		bomb := detonating[0]
+		detonating = detonating[1:]
+		// the bomb might have already been reached by an earlier explosion of the chain
+		if game.bombTiles[bomb.Position] != bomb {
+			continue
+		}
+		game.RemoveBomb(bomb)
 
-		if GlobalClients.Exists(user.UserId) && GlobalGames.Exists(GameId(user.GameId)) {
-			BombExploded(data)
+		bomb.DetonateTick = game.Tick
+		exploded := game.BombExploded(*bomb)
+		for _, direction := range exploded.ExplosionArea {
+			for _, pos := range direction {
+				if chained, ok := game.bombTiles[pos]; ok && chained.LandTick <= game.Tick {
+					detonating = append(detonating, chained)
+				}
+			}
 		}
-	}()
+	}
+}
+
+// SetBombTile moves the bomb to a tile, players standing on the tile can walk off it
+func (game *Game) SetBombTile(bomb *Bomb, pos Position) {
+	if game.bombTiles[bomb.Position] == bomb {
+		delete(game.bombTiles, bomb.Position)
+	}
+	bomb.Position = pos
+	game.bombTiles[pos] = bomb
+
+	bomb.passable = make(map[UserId]bool)
+	for _, player := range GlobalGames.ListGamePlayers(game.GameId) {
+		if containsPosition(game.GetUserCollidingTiles(AbsolutePosition(player.Position)), pos) {
+			bomb.passable[player.UserId] = true
+		}
+	}
 }
 
-// BombExploded Updates game and explosion area grid, send back data to client
-func BombExploded(data Data) {
-	user := GlobalClients.GetUser(UserId(data.UserId))
+// BombBlocks returns a boolean indicating whether there is a bomb on the tile which the user can't walk through.
+// Thrown bombs don't block anyone before they land.
+func (game *Game) BombBlocks(user *User, pos Position) bool {
+	bomb, ok := game.bombTiles[pos]
+	return ok && bomb.LandTick <= game.Tick && !bomb.passable[user.UserId]
+}
+
+// LeaveBombs makes the bombs the user has walked off solid for them
+func (game *Game) LeaveBombs(user *User, collidingTiles []Position) {
+	for _, bomb := range game.bombs {
+		if bomb.passable[user.UserId] && !containsPosition(collidingTiles, bomb.Position) {
+			delete(bomb.passable, user.UserId)
+		}
+	}
+}
+
+// NextBombType returns the type of the next bomb the user places
+func (game *Game) NextBombType(user *User) BombType {
+	if user.Powerups.BombType != PowerBomb {
+		return user.Powerups.BombType
+	}
+
+	for _, bomb := range game.bombs {
+		if bomb.UserId == user.UserId && bomb.Type == PowerBomb {
+			return NormalBomb
+		}
+	}
+	return PowerBomb
+}
+
+// FuseRunOut returns a boolean indicating whether the bomb explodes on the tick, remote bombs wait until they are detonated
+func (bomb Bomb) FuseRunOut(tick int) bool {
+	if bomb.DetonateTick == 0 {
+		return false
+	}
+	return bomb.DetonateTick <= tick
+}
+
+// Detonate explodes the oldest remote bomb of the user on the current tick, called by the game loop for queued detonate inputs
+func Detonate(user *User) {
 	game := GlobalGames.GetGame(GameId(user.GameId))
-	// recieve coordinates of bomb and player who put it
-	bomb := GetExplosionArea(data)
-	game.AddExplosionToGrid(bomb)
-	go func() {
-		time.Sleep(1 * time.Second)
-		if GlobalGames.Exists(game.GameId) {
-			game.RemoveExplosionFromGrid(bomb)
+	if user.Lives <= 0 {
+		return
+	}
+
+	for _, bomb := range game.bombs {
+		if bomb.UserId == user.UserId && bomb.Type == RemoteBomb && bomb.DetonateTick == 0 {
+			bomb.DetonateTick = game.Tick
+			return
+		}
+	}
+}
+
+// ExplosionRange returns how many tiles the explosion of the bomb reaches to each direction
+func (game *Game) ExplosionRange(bomb Bomb, user *User) int {
+	if bomb.Type == PowerBomb || game.MaxFlame() {
+		return int(math.Max(float64(game.Config.GridConfig.Width), float64(game.Config.GridConfig.Height)))
+	}
+	return user.Powerups.Flame
+}
+
+// RemoveBomb removes a bomb from the bombs of the game and from its tile
+func (game *Game) RemoveBomb(bomb *Bomb) {
+	delete(game.bombTiles, bomb.Position)
+
+	for i, liveBomb := range game.bombs {
+		if liveBomb == bomb {
+			game.bombs = append(game.bombs[:i], game.bombs[i+1:]...)
+			return
 		}
-	}()	
-	bomb.UserId = UserId(data.UserId)
+	}
+}
+
+// BombExploded Updates game and explosion area grid, sends back data to client. Returns the bomb with its explosion area.
+func (game *Game) BombExploded(bomb Bomb) Bomb {
+	// bombs of players who have left the game are credited to their record in the match
+	user := game.Participant(bomb.UserId)
+	if user == nil {
+		user = &User{UserId: bomb.UserId, Powerups: NewPlayerPowerUps()}
+	}
+
+	// barrels already broken by another explosion aren't credited again
+	barrelsBroken := game.BarrelsBroken
+	bomb = game.GetExplosionArea(bomb, game.ExplosionRange(bomb, user))
+	user.Stats.BarrelsBroken += game.BarrelsBroken - barrelsBroken
+	game.DestroyPowerups(bomb)
+	game.AddExplosionToGrid(bomb)
+	game.explosions = append(game.explosions, &ActiveExplosion{
+		Bomb:      bomb,
+		StartTick: game.Tick,
+	})
+
 	// check if any player is in the explosion area
 	for _, gamePlayer := range GlobalGames.ListGamePlayers(game.GameId) {
 		player := GlobalClients.GetUser(gamePlayer.UserId)
-		if game.PlayerInExplosion(*player, bomb) {
+		if game.PlayerInExplosion(*player, bomb) && game.CanHurt(bomb.UserId, player) {
 			// lose 1 life
-			game.LoseLife(player, 1)	
+			game.LoseLife(player, 1, bomb.UserId)
 		}
 	}
+
 	user.Powerups.Bombs += 1
 
-	err := GlobalGames.BroadcastToGame(game.GameId, Data{
-		Type:     "bombExploded",
-		UserId:   string(user.UserId),
-		// GameInfo: game.PrepareForSend(),
-		Bomb:     bomb,
+	game.Emit(BombExplodedEvent{
+		UserId: user.UserId,
+		Bomb:   bomb,
 	})
-	HandleError(err)
 
-	time.Sleep(1500 * time.Millisecond)
+	return bomb
+}
+
+// UpdateExplosions removes explosions from the grid, breaks the barrels they hit and sends the updated grid once they are over
+func (game *Game) UpdateExplosions() {
+	var activeExplosions []*ActiveExplosion
 
-	err = GlobalGames.BroadcastToGame(game.GameId, Data{
-		Type:     "updateGrid",
-		UserId:   data.UserId,
-		GameInfo: game.PrepareForSend(),
-		Bomb:     data.Bomb,
-	})
-	HandleError(err)
+	for _, explosion := range game.explosions {
+		elapsed := game.Tick - explosion.StartTick
+
+		if elapsed == game.Ticks(explosionTime) {
+			game.RemoveExplosionFromGrid(explosion.Bomb)
+		}
+		if elapsed == game.Ticks(barrelBreakTime) {
+			game.changeBarrelsToEmpty(explosion.Bomb)
+		}
+		if elapsed >= game.Ticks(gridUpdateTime) {
+			game.Emit(UpdateGridEvent{
+				UserId: explosion.Bomb.UserId,
+				Bomb:   explosion.Bomb,
+			})
+			continue
+		}
+
+		activeExplosions = append(activeExplosions, explosion)
+	}
+
+	game.explosions = activeExplosions
 }
 
 // AddExplosionToGrid Add explosion to ActiveExplosions grid
@@ -111,81 +277,33 @@ func (game *Game) RemoveExplosionFromGrid(bomb Bomb) {
 	}
 }
 
-// GetExplosionArea calculates explosion area to each direction
-func GetExplosionArea(data Data) Bomb {
-	user := GlobalClients.GetUser(UserId(data.UserId))
-	game := GlobalGames.GetGame(GameId(user.GameId))
+// GetExplosionArea calculates explosion area to each direction, pierce bombs explode through barrels
+func (game *Game) GetExplosionArea(bomb Bomb, explosionRange int) Bomb {
 	var wall, barrel = game.Config.GridConfig.WallBlock, game.Config.GridConfig.BarrelBlock
-	explosionRange := user.Powerups.Flame
-	bomb := data.Bomb
+	// right, left, down, up
+	var directions = []Position{{X: 1, Y: 0}, {X: -1, Y: 0}, {X: 0, Y: 1}, {X: 0, Y: -1}}
 
-	wg := new(sync.WaitGroup)
-	wg.Add(4)
-
-	for i := 0; i < 4; i++ {
-		bomb.ExplosionArea = append(bomb.ExplosionArea, []Position{})
-	}
+	bomb.ExplosionArea = nil
 	// The square the bomb is on
 	CheckTile(bomb.Position, game)
 
-	// right
-	go func() {
-		defer wg.Done()
-		for x := bomb.Position.X + 1; x <= bomb.Position.X+explosionRange; x++ {
-			tile := CheckTile(Position{x, bomb.Position.Y}, game)
+	for _, dir := range directions {
+		var area = []Position{}
+		for i := 1; i <= explosionRange; i++ {
+			pos := Position{X: bomb.Position.X + dir.X*i, Y: bomb.Position.Y + dir.Y*i}
+			tile := CheckTile(pos, game)
 			if tile == wall {
 				break
 			}
-			bomb.ExplosionArea[0] = append(bomb.ExplosionArea[0], Position{X: x, Y: bomb.Position.Y})
-			if tile == barrel {
+			area = append(area, pos)
+			// the explosion stops at barrels and at bombs, which it detonates
+			if placed, ok := game.bombTiles[pos]; tile == barrel && bomb.Type != PierceBomb || ok && placed.LandTick <= game.Tick {
 				break
 			}
 		}
-	}()
-	// left
-	go func() {
-		defer wg.Done()
-		for x := bomb.Position.X - 1; x >= bomb.Position.X-explosionRange; x-- {
-			tile := CheckTile(Position{x, bomb.Position.Y}, game)
-			if tile == wall {
-				break
-			}
-			bomb.ExplosionArea[1] = append(bomb.ExplosionArea[1], Position{X: x, Y: bomb.Position.Y})
-			if tile == barrel {
-				break
-			}
-		}
-	}()
-	//down
-	go func() {
-		defer wg.Done()
-		for y := bomb.Position.Y + 1; y <= bomb.Position.Y+explosionRange; y++ {
-			tile := CheckTile(Position{bomb.Position.X, y}, game)
-			if tile == wall {
-				break
-			}
-			bomb.ExplosionArea[2] = append(bomb.ExplosionArea[2], Position{X: bomb.Position.X, Y: y})
-			if tile == barrel {
-				break
-			}
-		}
-	}()
-	//up
-	go func() {
-		defer wg.Done()
-		for y := bomb.Position.Y - 1; y >= bomb.Position.Y-explosionRange; y-- {
-			tile := CheckTile(Position{bomb.Position.X, y}, game)
-			if tile == wall {
-				break
-			}
-			bomb.ExplosionArea[3] = append(bomb.ExplosionArea[3], Position{X: bomb.Position.X, Y: y})
-			if tile == barrel {
-				break
-			}
-		}
-	}()
+		bomb.ExplosionArea = append(bomb.ExplosionArea, area)
+	}
 
-	wg.Wait()
 	return bomb
 }
 
@@ -226,41 +344,47 @@ func CheckTile(pos Position, game *Game) int {
 		return wall
 	}
 
-	// Change Barrel to empty on grid
-	go changeBarrelsToEmpty(pos, game)
 	// Check Barrel contents
-	powerupName := game.BarrelContents[game.BarrelsBroken]
-	game.BarrelsBroken++
+	powerupName := game.BarrelPowerup(pos)
 	if powerupName != "Nothing" {
 		game.ActivePowerUps[pos.Y][pos.X] = game.Config.Powerups[powerupName].Icon
 	}
 	return barrel
 }
 
-// LoseLife player loses 1 life
-func (game *Game) LoseLife(user *User, amount int) {
-	if time.Now().Before(user.Invincibility) {
+// LoseLife player loses the given amount of lives, unless still invincible from the last life lost.
+// The lives are credited to the killer, an empty killer means the player was killed by the map.
+func (game *Game) LoseLife(user *User, amount int, killerId UserId) {
+	if game.Tick < user.Invincibility || user.Lives <= 0 {
 		return
 	}
 	// set invincibility for some time
-	user.Invincibility = time.Now().Add(3 * time.Second)
+	user.Invincibility = game.Tick + game.Ticks(invincibilityTime)
 	// lose life
-	GlobalClients.GetUser(user.UserId).Lives -= amount
+	if amount > user.Lives {
+		amount = user.Lives
+	}
+	user.Lives -= amount
+	game.CreditKill(user, amount, killerId)
 	// Let frontend know that user lost life
-	err := GlobalGames.BroadcastToGame(game.GameId, Data{
-		Type:     "loseLife",
-		UserId:   string(user.UserId),
-		GameInfo: game.PrepareForSend(),
+	game.Emit(LoseLifeEvent{
+		UserId:   user.UserId,
+		Lives:    user.Lives,
+		KillerId: killerId,
 	})
-	HandleError(err)
 
-	if len(game.AlivePlayers()) == 1 {
-		GameOver(game.GameId)
+	if user.Lives <= 0 {
+		game.Eliminate(user)
 	}
 }
 
-// changeBarrelsToEmpty Change grid barrels to empty tiles
-func changeBarrelsToEmpty(pos Position, game *Game)  {
-	time.Sleep(1300 * time.Millisecond)
-	game.Grid[pos.Y][pos.X] = game.Config.GridConfig.EmptyBlock
-}
\ No newline at end of file
+// changeBarrelsToEmpty Change grid barrels hit by the explosion to empty tiles
+func (game *Game) changeBarrelsToEmpty(bomb Bomb) {
+	for _, dir := range bomb.ExplosionArea {
+		for _, pos := range dir {
+			if game.Grid[pos.Y][pos.X] == game.Config.GridConfig.BarrelBlock {
+				game.Grid[pos.Y][pos.X] = game.Config.GridConfig.EmptyBlock
+			}
+		}
+	}
+}
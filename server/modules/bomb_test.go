@@ -0,0 +1,325 @@
+package modules
+
+import (
+	"reflect"
+	"testing"
+)
+
+// newTestArena begins a game on a grid without barrels, tests place the barrels and bombs they need
+func newTestArena(t *testing.T, players int) (*Game, []*User) {
+	game, users := newTestGame(t, players)
+	config := game.Config.GridConfig
+	game.Grid = config.NewEmptyGrid().PlaceWalls(config)
+	// bombs without a detonate tick don't explode on their own
+	game.Tick = 1
+	return game, users
+}
+
+// placeTestBomb places a bomb of the user which explodes on the current tick
+func placeTestBomb(game *Game, user *User, pos Position, bombType BombType) *Bomb {
+	bomb := &Bomb{UserId: user.UserId, Type: bombType, DetonateTick: game.Tick}
+	game.bombs = append(game.bombs, bomb)
+	game.SetBombTile(bomb, pos)
+	return bomb
+}
+
+func TestBombExplodedCreditsBarrels(t *testing.T) {
+	game, users := newTestArena(t, 2)
+	barrel := game.Config.GridConfig.BarrelBlock
+	game.Grid[3][4] = barrel
+	game.Grid[4][3] = barrel
+
+	// both bombs hit the barrel between them on the same tick, the first bomb also breaks the barrel below it
+	placeTestBomb(game, users[0], Position{X: 3, Y: 3}, NormalBomb)
+	placeTestBomb(game, users[1], Position{X: 5, Y: 3}, NormalBomb)
+	game.UpdateBombs()
+
+	if got := users[0].Stats.BarrelsBroken + users[1].Stats.BarrelsBroken; got != 2 {
+		t.Errorf("%d barrels credited, want 2", got)
+	}
+	if game.BarrelsBroken != 2 {
+		t.Errorf("%d barrels broken, want 2", game.BarrelsBroken)
+	}
+
+	// the barrels are still on the grid until the explosions end, hitting them again doesn't count
+	game.Tick++
+	credited := users[1].Stats.BarrelsBroken
+	placeTestBomb(game, users[1], Position{X: 3, Y: 3}, NormalBomb)
+	game.UpdateBombs()
+	if users[1].Stats.BarrelsBroken != credited || game.BarrelsBroken != 2 {
+		t.Errorf("barrels hit again were credited: %d credited, %d broken", users[1].Stats.BarrelsBroken-credited, game.BarrelsBroken)
+	}
+}
+
+func TestGetExplosionArea(t *testing.T) {
+	tests := []struct {
+		name      string
+		bomb      Bomb
+		rangeSize int
+		barrels   []Position
+		bombs     []Bomb
+		want      [][]Position // right, left, down, up
+	}{
+		{
+			name:      "open grid",
+			bomb:      Bomb{Position: Position{X: 5, Y: 5}},
+			rangeSize: 2,
+			want: [][]Position{
+				{{X: 6, Y: 5}, {X: 7, Y: 5}},
+				{{X: 4, Y: 5}, {X: 3, Y: 5}},
+				{{X: 5, Y: 6}, {X: 5, Y: 7}},
+				{{X: 5, Y: 4}, {X: 5, Y: 3}},
+			},
+		},
+		{
+			name:      "walls stop the explosion",
+			bomb:      Bomb{Position: Position{X: 4, Y: 5}},
+			rangeSize: 2,
+			want: [][]Position{
+				{{X: 5, Y: 5}, {X: 6, Y: 5}},
+				{{X: 3, Y: 5}, {X: 2, Y: 5}},
+				{},
+				{},
+			},
+		},
+		{
+			name:      "barrels stop the explosion",
+			bomb:      Bomb{Position: Position{X: 5, Y: 5}},
+			rangeSize: 3,
+			barrels:   []Position{{X: 6, Y: 5}, {X: 5, Y: 7}},
+			want: [][]Position{
+				{{X: 6, Y: 5}},
+				{{X: 4, Y: 5}, {X: 3, Y: 5}, {X: 2, Y: 5}},
+				{{X: 5, Y: 6}, {X: 5, Y: 7}},
+				{{X: 5, Y: 4}, {X: 5, Y: 3}, {X: 5, Y: 2}},
+			},
+		},
+		{
+			name:      "bombs stop the explosion",
+			bomb:      Bomb{Position: Position{X: 5, Y: 5}},
+			rangeSize: 3,
+			bombs:     []Bomb{{Position: Position{X: 7, Y: 5}}},
+			want: [][]Position{
+				{{X: 6, Y: 5}, {X: 7, Y: 5}},
+				{{X: 4, Y: 5}, {X: 3, Y: 5}, {X: 2, Y: 5}},
+				{{X: 5, Y: 6}, {X: 5, Y: 7}, {X: 5, Y: 8}},
+				{{X: 5, Y: 4}, {X: 5, Y: 3}, {X: 5, Y: 2}},
+			},
+		},
+		{
+			name:      "pierce bombs explode through barrels",
+			bomb:      Bomb{Position: Position{X: 5, Y: 5}, Type: PierceBomb},
+			rangeSize: 3,
+			barrels:   []Position{{X: 6, Y: 5}, {X: 7, Y: 5}, {X: 5, Y: 6}},
+			want: [][]Position{
+				{{X: 6, Y: 5}, {X: 7, Y: 5}, {X: 8, Y: 5}},
+				{{X: 4, Y: 5}, {X: 3, Y: 5}, {X: 2, Y: 5}},
+				{{X: 5, Y: 6}, {X: 5, Y: 7}, {X: 5, Y: 8}},
+				{{X: 5, Y: 4}, {X: 5, Y: 3}, {X: 5, Y: 2}},
+			},
+		},
+		{
+			name:      "pierce bombs are stopped by walls and bombs",
+			bomb:      Bomb{Position: Position{X: 4, Y: 5}, Type: PierceBomb},
+			rangeSize: 3,
+			barrels:   []Position{{X: 5, Y: 5}},
+			bombs:     []Bomb{{Position: Position{X: 6, Y: 5}}},
+			want: [][]Position{
+				{{X: 5, Y: 5}, {X: 6, Y: 5}},
+				{{X: 3, Y: 5}, {X: 2, Y: 5}, {X: 1, Y: 5}},
+				{},
+				{},
+			},
+		},
+		{
+			name:      "thrown bombs in the air don't stop the explosion",
+			bomb:      Bomb{Position: Position{X: 5, Y: 5}},
+			rangeSize: 2,
+			bombs:     []Bomb{{Position: Position{X: 6, Y: 5}, LandTick: 10}},
+			want: [][]Position{
+				{{X: 6, Y: 5}, {X: 7, Y: 5}},
+				{{X: 4, Y: 5}, {X: 3, Y: 5}},
+				{{X: 5, Y: 6}, {X: 5, Y: 7}},
+				{{X: 5, Y: 4}, {X: 5, Y: 3}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			game, _ := newTestArena(t, 2)
+			for _, pos := range test.barrels {
+				game.Grid[pos.Y][pos.X] = game.Config.GridConfig.BarrelBlock
+			}
+			for i := range test.bombs {
+				game.SetBombTile(&test.bombs[i], test.bombs[i].Position)
+			}
+
+			got := game.GetExplosionArea(test.bomb, test.rangeSize).ExplosionArea
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("explosion area = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestChainReaction(t *testing.T) {
+	game, users := newTestArena(t, 2)
+
+	// the first bomb reaches the second, which reaches the third, the last bomb is out of reach
+	first := placeTestBomb(game, users[0], Position{X: 3, Y: 3}, NormalBomb)
+	second := placeTestBomb(game, users[1], Position{X: 4, Y: 3}, NormalBomb)
+	third := placeTestBomb(game, users[0], Position{X: 5, Y: 3}, NormalBomb)
+	last := placeTestBomb(game, users[1], Position{X: 9, Y: 3}, NormalBomb)
+	second.DetonateTick = game.Tick + 100
+	third.DetonateTick = game.Tick + 100
+	last.DetonateTick = game.Tick + 100
+
+	game.UpdateBombs()
+
+	for _, bomb := range []*Bomb{first, second, third} {
+		if _, ok := game.bombTiles[bomb.Position]; ok {
+			t.Errorf("bomb at %v didn't explode", bomb.Position)
+		}
+		if bomb.DetonateTick != game.Tick {
+			t.Errorf("bomb at %v detonated on tick %d, want the tick of the chain %d", bomb.Position, bomb.DetonateTick, game.Tick)
+		}
+	}
+	if game.bombTiles[last.Position] != last || len(game.bombs) != 1 {
+		t.Errorf("bomb out of reach exploded, %d bombs left", len(game.bombs))
+	}
+	if len(game.explosions) != 3 {
+		t.Errorf("%d explosions, want 3", len(game.explosions))
+	}
+}
+
+func TestExplosionRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		bombType BombType
+		flame    int
+		want     int
+	}{
+		{"normal bomb", NormalBomb, 2, 2},
+		{"pierce bomb", PierceBomb, 3, 3},
+		{"remote bomb", RemoteBomb, 1, 1},
+		{"power bomb", PowerBomb, 1, 15},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			game, users := newTestArena(t, 2)
+			users[0].Powerups.Flame = test.flame
+			if got := game.ExplosionRange(Bomb{Type: test.bombType}, users[0]); got != test.want {
+				t.Errorf("range = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRemoteBombsOfGoneOwners(t *testing.T) {
+	tests := []struct {
+		name    string
+		owner   func(user *User)
+		explode bool
+	}{
+		{"owner playing", func(user *User) {}, false},
+		{"owner eliminated", func(user *User) { user.Lives = 0 }, true},
+		{"owner left the game", func(user *User) {
+			LeaveLobby(user)
+			JoinLobby(user, "global")
+		}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			game, users := newTestArena(t, 3)
+			bomb := placeTestBomb(game, users[2], Position{X: 3, Y: 3}, RemoteBomb)
+			bomb.DetonateTick = 0
+
+			test.owner(users[2])
+			game.UpdateBombs()
+
+			if _, ok := game.bombTiles[bomb.Position]; ok == test.explode {
+				t.Errorf("bomb exploded = %t, want %t", !ok, test.explode)
+			}
+		})
+	}
+}
+
+func TestBombOfLeaverExplodes(t *testing.T) {
+	game, users := newTestArena(t, 3)
+	tileSize := game.Config.GridConfig.Tilesize
+	game.Grid[3][4] = game.Config.GridConfig.BarrelBlock
+	users[0].Position = Position{X: 3 * tileSize, Y: 4 * tileSize}
+	users[0].Invincibility = 0
+
+	leaver := users[2]
+	placeTestBomb(game, leaver, Position{X: 3, Y: 3}, NormalBomb)
+	LeaveLobby(leaver)
+	JoinLobby(leaver, "global")
+	game.UpdateBombs()
+
+	exploded := false
+	for _, event := range game.events {
+		if msg, ok := event.Payload.(BombExplodedEvent); ok && msg.UserId == leaver.UserId {
+			exploded = true
+		}
+	}
+	if !exploded {
+		t.Fatal("bomb of the leaver didn't explode")
+	}
+	if users[0].Lives != game.Config.Lives-1 {
+		t.Errorf("player in the explosion has %d lives, want %d", users[0].Lives, game.Config.Lives-1)
+	}
+
+	// the explosion is credited to the record of the leaver, not to the user in the global chat
+	record := game.Participant(leaver.UserId)
+	if record == nil || record == leaver {
+		t.Fatalf("record of the leaver = %v", record)
+	}
+	if record.Stats.BarrelsBroken != 1 || record.Stats.Kills != 1 {
+		t.Errorf("leaver record has %d barrels and %d kills, want 1 and 1", record.Stats.BarrelsBroken, record.Stats.Kills)
+	}
+	if leaver.Stats != (MatchStats{}) {
+		t.Errorf("leaver in the global chat got stats %+v", leaver.Stats)
+	}
+}
+
+func TestBombPassOff(t *testing.T) {
+	tests := []struct {
+		name   string
+		player int // index of the player walking onto the bomb, player 0 stands on it when it's placed
+		setup  func(game *Game, bomb *Bomb, users []*User)
+		blocks bool
+	}{
+		{"owner standing on the bomb", 0, func(game *Game, bomb *Bomb, users []*User) {}, false},
+		{"other player", 1, func(game *Game, bomb *Bomb, users []*User) {}, true},
+		{"owner still overlapping the bomb", 0, func(game *Game, bomb *Bomb, users []*User) {
+			game.LeaveBombs(users[0], []Position{{X: 3, Y: 3}, {X: 4, Y: 3}})
+		}, false},
+		{"owner walked off the bomb", 0, func(game *Game, bomb *Bomb, users []*User) {
+			game.LeaveBombs(users[0], []Position{{X: 4, Y: 3}})
+		}, true},
+		{"thrown bomb in the air", 1, func(game *Game, bomb *Bomb, users []*User) {
+			bomb.LandTick = game.Tick + 5
+		}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			game, users := newTestArena(t, 2)
+			tileSize := game.Config.GridConfig.Tilesize
+			users[0].Position = Position{X: 3*tileSize + 4, Y: 3*tileSize + 4}
+			users[1].Position = Position{X: 7*tileSize + 4, Y: 7*tileSize + 4}
+
+			bomb := placeTestBomb(game, users[0], Position{X: 3, Y: 3}, NormalBomb)
+			bomb.DetonateTick = game.Tick + 100
+			test.setup(game, bomb, users)
+
+			if got := game.BombBlocks(users[test.player], bomb.Position); got != test.blocks {
+				t.Errorf("bomb blocks = %t, want %t", got, test.blocks)
+			}
+		})
+	}
+}
@@ -1,34 +1,58 @@
 package modules
 
 // Authenticate checks and registers user
-func Authenticate(data Data) {
-	uId := UserId(data.UserId)
-	user := GlobalClients.GetUser(uId)
-
-	user.Username = data.Username
-	user.Color = data.Color
-
-	err := user.Conn.Send(Data{
-		Type:     "createUser",
-		Username: user.Username,
-		Color:    user.Color,
-		UserId:   string(user.UserId),
+func Authenticate(user *User, msg AuthenticateInput) {
+	// the profile is saved to disk before the game of the user is locked
+	profile := LoadProfile(msg.AccountToken, msg.Username, msg.Color)
+
+	_, unlock := LockUser(user)
+	defer unlock()
+	user.Username = msg.Username
+	user.Color = msg.Color
+	user.ProfileId = profile.ProfileId
+	user.AccountToken = profile.Token
+	NewSession(user)
+
+	err := user.Conn.Send(UserCreated{
+		Username:     user.Username,
+		Color:        user.Color,
+		UserId:       user.UserId,
+		SessionToken: user.SessionToken,
+		ProfileId:    user.ProfileId,
+		AccountToken: user.AccountToken,
 	})
 	HandleError(err)
 
 }
 
 // SendMesage sends chat message to all players in the lobby
-func SendMessage(data Data) {
-	chat := Data{
-		Type:     "message",
-		Message:  data.Message,
-		Username: data.Username,
-		Date:     CurrentTime(),
-		Color:    data.Color,
+func SendMessage(user *User, msg SendMessageInput) {
+	chat := ChatMessage{
+		Message:   msg.Message,
+		Username:  msg.Username,
+		Date:      CurrentTime(),
+		Color:     msg.Color,
+		Spectator: user.Spectator,
+		Team:      msg.Team && user.Team != 0 && !user.Spectator,
+	}
+
+	// spectators have their own chat, so they can't tell the players what they see
+	if user.Spectator {
+		err := GlobalGames.BroadcastToSpectators(GameId(user.GameId), chat)
+		HandleError(err)
+		return
+	}
+
+	if chat.Team {
+		for _, player := range GlobalGames.ListGamePlayers(GameId(user.GameId)) {
+			if player.Team == user.Team {
+				err := player.Conn.Send(chat)
+				HandleError(err)
+			}
+		}
+		return
 	}
 
-	user := GlobalClients.GetUser(UserId(data.UserId))
 	err := GlobalGames.BroadcastToGame(GameId(user.GameId), chat)
 	HandleError(err)
 }
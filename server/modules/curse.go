@@ -0,0 +1,104 @@
+package modules
+
+import (
+	"math/rand"
+	"time"
+)
+
+// CurseType is the effect of a skull powerup
+type CurseType string
+
+const (
+	NoCurse          CurseType = ""
+	ReversedControls CurseType = "reversed" // Moves go to the opposite direction
+	MinimumSpeed     CurseType = "slow"     // Moves at the lowest speed
+	NoBombs          CurseType = "noBombs"  // Can't place bombs
+	Diarrhea         CurseType = "diarrhea" // Places bombs whenever possible
+)
+
+var curses = []CurseType{ReversedControls, MinimumSpeed, NoBombs, Diarrhea}
+
+const (
+	curseTime         = 10 * time.Second
+	curseImmunityTime = 1 * time.Second // How long a player who passed a curse on can't get it back by contact
+	cursedSpeed       = 2
+)
+
+// Curse is a timed negative effect of a skull powerup
+type Curse struct {
+	Type       CurseType
+	ExpireTick int
+}
+
+// reversedDirections maps move directions to their opposites
+var reversedDirections = map[string]string{
+	"up":    "down",
+	"down":  "up",
+	"left":  "right",
+	"right": "left",
+	"stop":  "stop",
+}
+
+// CurseUser gives the user a random curse for the duration, curseTime if it's zero
+func (game *Game) CurseUser(user *User, duration time.Duration) {
+	if duration == 0 {
+		duration = curseTime
+	}
+
+	game.SetCurse(user, Curse{
+		Type:       curses[rand.Intn(len(curses))],
+		ExpireTick: game.Tick + game.Ticks(duration),
+	}, "")
+}
+
+// SetCurse replaces the curse of the user and tells the players about it, fromId is the player who passed the curse on by contact
+func (game *Game) SetCurse(user *User, curse Curse, fromId UserId) {
+	user.Curse = curse
+
+	game.Emit(CurseEvent{
+		UserId:     user.UserId,
+		Curse:      curse.Type,
+		ExpireTick: curse.ExpireTick,
+		FromUserId: fromId,
+	})
+}
+
+// UpdateCurses ends the expired curses, passes curses on to the players the cursed players touch and places the bombs of players with diarrhea
+func (game *Game) UpdateCurses() {
+	for _, gamePlayer := range game.AlivePlayers() {
+		user := GlobalClients.GetUser(gamePlayer.UserId)
+		if user == nil || user.Curse.Type == NoCurse {
+			continue
+		}
+
+		if user.Curse.ExpireTick <= game.Tick {
+			game.SetCurse(user, Curse{}, "")
+			continue
+		}
+
+		for _, otherPlayer := range game.AlivePlayers() {
+			other := GlobalClients.GetUser(otherPlayer.UserId)
+			if other == nil || other == user || other.Curse.Type != NoCurse || game.Tick < other.CurseImmunity {
+				continue
+			}
+			if game.PlayersTouch(user, other) {
+				curse := user.Curse
+				game.SetCurse(user, Curse{}, "")
+				user.CurseImmunity = game.Tick + game.Ticks(curseImmunityTime)
+				game.SetCurse(other, curse, user.UserId)
+				break
+			}
+		}
+
+		if user.Curse.Type == Diarrhea {
+			BombPlaced(user)
+		}
+	}
+}
+
+// PlayersTouch returns a boolean indicating whether the characters of the players overlap
+func (game *Game) PlayersTouch(user *User, other *User) bool {
+	size := game.Config.CharacterSize
+	return user.Position.X+size > other.Position.X && user.Position.X < other.Position.X+size &&
+		user.Position.Y+size > other.Position.Y && user.Position.Y < other.Position.Y+size
+}
@@ -0,0 +1,102 @@
+package modules
+
+import "testing"
+
+func TestUpdateCurses(t *testing.T) {
+	tests := []struct {
+		name    string
+		expire  int  // ticks until the curse of player 0 expires
+		touch   bool // player 1 stands on player 0
+		setup   func(game *Game, users []*User)
+		cursed  [2]CurseType
+		passed  bool // player 1 got the curse from player 0
+		immune0 bool // player 0 can't get the curse back by contact
+	}{
+		{
+			name:   "curse lasts",
+			expire: 5,
+			cursed: [2]CurseType{MinimumSpeed, NoCurse},
+		},
+		{
+			name:   "curse expires",
+			expire: 0,
+			touch:  true,
+			cursed: [2]CurseType{NoCurse, NoCurse},
+		},
+		{
+			name:    "passed on by touching",
+			expire:  5,
+			touch:   true,
+			cursed:  [2]CurseType{NoCurse, MinimumSpeed},
+			passed:  true,
+			immune0: true,
+		},
+		{
+			name:   "touched player is immune",
+			expire: 5,
+			touch:  true,
+			setup: func(game *Game, users []*User) {
+				users[1].CurseImmunity = game.Tick + 1
+			},
+			cursed: [2]CurseType{MinimumSpeed, NoCurse},
+		},
+		{
+			name:   "touched player is already cursed",
+			expire: 5,
+			touch:  true,
+			setup: func(game *Game, users []*User) {
+				users[1].Curse = Curse{Type: ReversedControls, ExpireTick: game.Tick + 5}
+			},
+			cursed: [2]CurseType{MinimumSpeed, ReversedControls},
+		},
+		{
+			name:   "touched player is dead",
+			expire: 5,
+			touch:  true,
+			setup: func(game *Game, users []*User) {
+				users[1].Lives = 0
+			},
+			cursed: [2]CurseType{MinimumSpeed, NoCurse},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			game, users := newTestArena(t, 2)
+			tileSize := game.Config.GridConfig.Tilesize
+			users[0].Position = Position{X: 3 * tileSize, Y: 3 * tileSize}
+			users[1].Position = Position{X: 7 * tileSize, Y: 7 * tileSize}
+			if test.touch {
+				users[1].Position = Position{X: 3*tileSize + 10, Y: 3 * tileSize}
+			}
+			users[0].Curse = Curse{Type: MinimumSpeed, ExpireTick: game.Tick + test.expire}
+			if test.setup != nil {
+				test.setup(game, users)
+			}
+
+			game.UpdateCurses()
+
+			for i, user := range users {
+				if user.Curse.Type != test.cursed[i] {
+					t.Errorf("player %d curse = %q, want %q", i, user.Curse.Type, test.cursed[i])
+				}
+			}
+			if test.passed && users[1].Curse.ExpireTick != game.Tick+test.expire {
+				t.Errorf("passed on curse expires on tick %d, want %d", users[1].Curse.ExpireTick, game.Tick+test.expire)
+			}
+			if immune := game.Tick < users[0].CurseImmunity; immune != test.immune0 {
+				t.Errorf("player 0 immune = %t, want %t", immune, test.immune0)
+			}
+
+			passed := false
+			for _, event := range game.events {
+				if msg, ok := event.Payload.(CurseEvent); ok && msg.UserId == users[1].UserId && msg.FromUserId == users[0].UserId {
+					passed = true
+				}
+			}
+			if passed != test.passed {
+				t.Errorf("curse passed on event = %t, want %t", passed, test.passed)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package modules
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// DropMode decides how powerups are put into the barrels
+type DropMode string
+
+const (
+	FixedDrops    DropMode = "fixed"    // Every powerup is in exactly Amount barrels
+	WeightedDrops DropMode = "weighted" // Every barrel gets a powerup by the Weight of the powerups, "Nothing" weighs the empty barrels
+	FairDrops     DropMode = "fair"     // Weighted drops, each quadrant of the grid gets the same powerups
+)
+
+// GetWeightedPowerups returns the powerups of the barrels on the grid by their position.
+// The Minimum of every powerup is placed first, spread over the quadrants, the rest of the barrels are filled by the powerup weights.
+func (config GameConfig) GetWeightedPowerups(grid Grid) map[Position]PowerupName {
+	quadrants := config.GridConfig.BarrelQuadrants(grid)
+	contents := make(map[Position]PowerupName)
+
+	// guaranteed minimums go round the quadrants, starting from a random one
+	quadrant := rand.Intn(len(quadrants))
+	for _, name := range sortedPowerupNames(config.Powerups) {
+		for i := 0; i < config.Powerups[name].Minimum; i++ {
+			for tries := 0; tries < len(quadrants) && len(quadrants[quadrant]) == 0; tries++ {
+				quadrant = (quadrant + 1) % len(quadrants)
+			}
+			if len(quadrants[quadrant]) == 0 {
+				break
+			}
+			contents[quadrants[quadrant][0]] = name
+			quadrants[quadrant] = quadrants[quadrant][1:]
+			quadrant = (quadrant + 1) % len(quadrants)
+		}
+	}
+
+	if config.DropMode == FairDrops {
+		// every quadrant gets the same draws for as many barrels as the smallest quadrant has left
+		shared := len(quadrants[0])
+		for _, barrels := range quadrants {
+			if len(barrels) < shared {
+				shared = len(barrels)
+			}
+		}
+		draws := make([]PowerupName, shared)
+		for i := range draws {
+			draws[i] = config.DrawPowerup()
+		}
+		for i, barrels := range quadrants {
+			for j, name := range draws {
+				contents[barrels[j]] = name
+			}
+			quadrants[i] = barrels[shared:]
+		}
+	}
+
+	for _, barrels := range quadrants {
+		for _, pos := range barrels {
+			contents[pos] = config.DrawPowerup()
+		}
+	}
+
+	return contents
+}
+
+// DrawPowerup picks a random powerup, the chance of each powerup is its Weight compared to the total weight
+func (config GameConfig) DrawPowerup() PowerupName {
+	names := sortedPowerupNames(config.Powerups)
+
+	var total float64
+	for _, name := range names {
+		total += config.Powerups[name].Weight
+	}
+	if total <= 0 {
+		return "Nothing"
+	}
+
+	draw := rand.Float64() * total
+	for _, name := range names {
+		draw -= config.Powerups[name].Weight
+		if draw < 0 {
+			return name
+		}
+	}
+	return "Nothing"
+}
+
+// BarrelQuadrants returns the barrel positions of each quadrant of the grid in a random order: top left, top right, bottom left and bottom right.
+// The halves of the middle row and column are shared out to the quadrants in a pinwheel, so every quadrant gets one.
+func (config GridConfig) BarrelQuadrants(grid Grid) [4][]Position {
+	var quadrants [4][]Position
+	midX, midY := config.Width/2, config.Height/2
+
+	for y, row := range grid {
+		for x, tile := range row {
+			if tile != config.BarrelBlock {
+				continue
+			}
+
+			var quadrant int
+			switch {
+			case x == midX && y < midY:
+				quadrant = 1
+			case x == midX && y > midY:
+				quadrant = 2
+			case y == midY && x > midX:
+				quadrant = 3
+			case y == midY:
+				quadrant = 0
+			default:
+				if x > midX {
+					quadrant++
+				}
+				if y > midY {
+					quadrant += 2
+				}
+			}
+			quadrants[quadrant] = append(quadrants[quadrant], Position{X: x, Y: y})
+		}
+	}
+
+	for _, barrels := range quadrants {
+		rand.Shuffle(len(barrels), func(i, j int) {
+			barrels[i], barrels[j] = barrels[j], barrels[i]
+		})
+	}
+
+	return quadrants
+}
+
+// sortedPowerupNames returns the names of the powerups in a fixed order, so random draws only depend on the random numbers
+func sortedPowerupNames(powerups map[PowerupName]Powerup) []PowerupName {
+	names := make([]PowerupName, 0, len(powerups))
+	for name := range powerups {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+
+	return names
+}
+
+// BarrelPowerup returns the powerup in the barrel at the position, which is broken by an explosion.
+// A barrel hit again before it's emptied, by the same or another explosion, is already broken and returns "Nothing".
+func (game *Game) BarrelPowerup(pos Position) PowerupName {
+	if game.brokenBarrels[pos] {
+		return "Nothing"
+	}
+	game.brokenBarrels[pos] = true
+	game.BarrelsBroken++
+
+	name := PowerupName("Nothing")
+	if game.barrelPowerups == nil {
+		if game.BarrelsBroken <= len(game.BarrelContents) {
+			name = game.BarrelContents[game.BarrelsBroken-1]
+		}
+	} else if weighted, ok := game.barrelPowerups[pos]; ok {
+		name = weighted
+	}
+
+	game.replay.RecordDrop(game.Tick, pos, name)
+	return name
+}
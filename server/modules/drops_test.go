@@ -0,0 +1,85 @@
+package modules
+
+import "testing"
+
+// barrelPositions returns the positions of the barrels on the grid of the game
+func barrelPositions(game *Game) []Position {
+	var barrels []Position
+	for y, row := range game.Grid {
+		for x, tile := range row {
+			if tile == game.Config.GridConfig.BarrelBlock {
+				barrels = append(barrels, Position{X: x, Y: y})
+			}
+		}
+	}
+	return barrels
+}
+
+func TestBarrelPowerup(t *testing.T) {
+	tests := []struct {
+		name string
+		mode DropMode
+		hits int // how many explosions hit each barrel
+	}{
+		{"fixed drops", FixedDrops, 1},
+		{"fixed drops hit by many explosions", FixedDrops, 3},
+		{"weighted drops", WeightedDrops, 1},
+		{"weighted drops hit by many explosions", WeightedDrops, 3},
+		{"fair drops hit by many explosions", FairDrops, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := NewGameConfig()
+			config.DropMode = test.mode
+			game, _ := newTestLobby(t, config, 2)
+			game.BeginRound()
+
+			barrels := barrelPositions(game)
+			if test.mode == FixedDrops && len(game.BarrelContents) != len(barrels) {
+				t.Fatalf("%d barrel contents for %d barrels", len(game.BarrelContents), len(barrels))
+			}
+
+			drops := make(map[PowerupName]int)
+			for hit := 0; hit < test.hits; hit++ {
+				for _, pos := range barrels {
+					name := game.BarrelPowerup(pos)
+					if hit > 0 && name != "Nothing" {
+						t.Fatalf("barrel at %v dropped %s on hit %d", pos, name, hit+1)
+					}
+					drops[name]++
+				}
+			}
+
+			if game.BarrelsBroken != len(barrels) {
+				t.Errorf("%d barrels broken, want %d", game.BarrelsBroken, len(barrels))
+			}
+			if len(game.replay.Drops) != len(barrels) {
+				t.Errorf("%d drops recorded, want %d", len(game.replay.Drops), len(barrels))
+			}
+			if test.mode == FixedDrops {
+				for name, powerup := range config.Powerups {
+					if name != "Nothing" && drops[name] != powerup.Amount {
+						t.Errorf("%s dropped %d times, want %d", name, drops[name], powerup.Amount)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestBarrelPowerupWithoutContents(t *testing.T) {
+	game, _ := newTestGame(t, 2)
+	// more barrels than contents, the barrels without contents are empty
+	game.BarrelContents = []PowerupName{"Bomb"}
+
+	for i, pos := range barrelPositions(game) {
+		want := PowerupName("Nothing")
+		if i == 0 {
+			want = "Bomb"
+		}
+		if name := game.BarrelPowerup(pos); name != want {
+			t.Fatalf("barrel %d dropped %s, want %s", i+1, name, want)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package modules
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"time"
+)
+
+const (
+	curseStat    = "Curse" // Stat of powerups which curse the player instead of changing PlayerPowerUps
+	maxBlockIcon = 6       // Icons up to this are used by the grid blocks
+)
+
+//go:embed powerups.json
+var defaultPowerups []byte
+
+// PowerupDefinitions are the powerups of new games, they are read from the powerups file
+var PowerupDefinitions = mustParsePowerups(defaultPowerups)
+
+// TimedEffect is a powerup effect which is undone when it expires
+type TimedEffect struct {
+	Stat       string
+	Change     int    // Change of an integer stat
+	Previous   string // Value of a text stat before the effect
+	ExpireTick int
+}
+
+// LoadPowerups replaces the powerup definitions with the ones in the JSON file
+func LoadPowerups(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	powerups, err := ParsePowerups(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	PowerupDefinitions = powerups
+
+	return nil
+}
+
+// ParsePowerups decodes a list of powerup definitions and checks that they change existing stats
+func ParsePowerups(data []byte) (map[PowerupName]Powerup, error) {
+	var list []Powerup
+	err := json.Unmarshal(data, &list)
+	if err != nil {
+		return nil, err
+	}
+
+	powerups := map[PowerupName]Powerup{"Nothing": {Name: "Nothing"}}
+	icons := make(map[int]PowerupName)
+	for _, powerup := range list {
+		// empty barrels only have a drop weight
+		if powerup.Name == "Nothing" {
+			powerups["Nothing"] = Powerup{Name: "Nothing", Weight: powerup.Weight}
+			continue
+		}
+		if powerup.Name == "" {
+			return nil, fmt.Errorf("powerup with icon %d has no name", powerup.Icon)
+		}
+		if powerup.Icon <= maxBlockIcon {
+			return nil, fmt.Errorf("powerup '%s' can't use icon %d, icons up to %d are grid blocks", powerup.Name, powerup.Icon, maxBlockIcon)
+		}
+		if name, ok := icons[powerup.Icon]; ok {
+			return nil, fmt.Errorf("powerup '%s' can't use icon %d, it belongs to '%s'", powerup.Name, powerup.Icon, name)
+		}
+		if powerup.Stat != curseStat && !validStat(powerup.Stat) {
+			return nil, fmt.Errorf("powerup '%s' changes unknown stat '%s'", powerup.Name, powerup.Stat)
+		}
+
+		icons[powerup.Icon] = powerup.Name
+		powerups[powerup.Name] = powerup
+	}
+
+	return powerups, nil
+}
+
+// mustParsePowerups parses the built-in powerup definitions
+func mustParsePowerups(data []byte) map[PowerupName]Powerup {
+	powerups, err := ParsePowerups(data)
+	if err != nil {
+		panic(err)
+	}
+	return powerups
+}
+
+// CopyPowerups returns a copy of the powerup definitions which a game can change
+func CopyPowerups(powerups map[PowerupName]Powerup) map[PowerupName]Powerup {
+	copied := make(map[PowerupName]Powerup, len(powerups))
+	for name, powerup := range powerups {
+		copied[name] = powerup
+	}
+	return copied
+}
+
+// validStat returns a boolean indicating whether the stat is an integer or text field of PlayerPowerUps
+func validStat(stat string) bool {
+	field, ok := reflect.TypeOf(PlayerPowerUps{}).FieldByName(stat)
+	return ok && (field.Type.Kind() == reflect.Int || field.Type.Kind() == reflect.String)
+}
+
+// PowerupByIcon returns the powerup shown with the icon on the grid
+func (config GameConfig) PowerupByIcon(icon int) (Powerup, bool) {
+	for _, powerup := range config.Powerups {
+		if powerup.Icon == icon && powerup.Name != "Nothing" {
+			return powerup, true
+		}
+	}
+	return Powerup{}, false
+}
+
+// Apply changes the stat of the powerup: integer stats are increased by its Step up to its Max, text stats are set to its Value.
+// offset is added to the stat before it is compared to the Max. Returns the effect so it can be undone.
+func (powerups *PlayerPowerUps) Apply(powerup Powerup, offset int) TimedEffect {
+	effect := TimedEffect{Stat: powerup.Stat}
+	field := reflect.ValueOf(powerups).Elem().FieldByName(powerup.Stat)
+
+	switch field.Kind() {
+	case reflect.Int:
+		before := int(field.Int())
+		after := powerup.Add(before+offset) - offset
+		field.SetInt(int64(after))
+		effect.Change = after - before
+	case reflect.String:
+		effect.Previous = field.String()
+		field.SetString(powerup.Value)
+	}
+
+	return effect
+}
+
+// Undo reverts an effect applied to the stats
+func (powerups *PlayerPowerUps) Undo(effect TimedEffect) {
+	field := reflect.ValueOf(powerups).Elem().FieldByName(effect.Stat)
+
+	switch field.Kind() {
+	case reflect.Int:
+		field.SetInt(field.Int() - int64(effect.Change))
+	case reflect.String:
+		field.SetString(effect.Previous)
+	}
+}
+
+// ApplyPowerup applies the effect of a powerup to the user, effects with a Duration are undone by UpdateEffects
+func (game *Game) ApplyPowerup(user *User, powerup Powerup) {
+	duration := time.Duration(powerup.Duration * float64(time.Second))
+
+	if powerup.Stat == curseStat {
+		game.CurseUser(user, duration)
+		return
+	}
+
+	effect := user.Powerups.Apply(powerup, game.StatOffset(user, powerup.Stat))
+	if duration != 0 {
+		effect.ExpireTick = game.Tick + game.Ticks(duration)
+		user.Effects = append(user.Effects, effect)
+	}
+	game.UpdateMaxed(user)
+}
+
+// UpdateEffects undoes the timed powerup effects which have expired
+func (game *Game) UpdateEffects() {
+	for _, player := range GlobalGames.ListGamePlayers(game.GameId) {
+		user := GlobalClients.GetUser(player.UserId)
+		if user == nil || len(user.Effects) == 0 {
+			continue
+		}
+
+		var active []TimedEffect
+		for i, effect := range user.Effects {
+			if effect.ExpireTick > game.Tick {
+				active = append(active, effect)
+				continue
+			}
+			// a later effect on the same text stat is still shown, it restores the value from before this effect when it's undone
+			if later := laterTextEffect(user.Effects[i+1:], effect.Stat); later != nil {
+				later.Previous = effect.Previous
+				continue
+			}
+			user.Powerups.Undo(effect)
+		}
+		if len(active) != len(user.Effects) {
+			user.Effects = active
+			game.UpdateMaxed(user)
+		}
+	}
+}
+
+// laterTextEffect returns the first of the effects which changes the same text stat, nil if there is none or the stat is an integer
+func laterTextEffect(effects []TimedEffect, stat string) *TimedEffect {
+	field, ok := reflect.TypeOf(PlayerPowerUps{}).FieldByName(stat)
+	if !ok || field.Type.Kind() != reflect.String {
+		return nil
+	}
+
+	for i := range effects {
+		if effects[i].Stat == stat {
+			return &effects[i]
+		}
+	}
+	return nil
+}
+
+// StatOffset returns how much is added to the stat before comparing it to the Max of a powerup, placed bombs count towards the bomb maximum
+func (game *Game) StatOffset(user *User, stat string) int {
+	if stat == "Bombs" {
+		return game.PlacedBombs(user)
+	}
+	return 0
+}
+
+// UpdateMaxed marks the stats of the user which have reached the Max of their powerup
+func (game *Game) UpdateMaxed(user *User) {
+	user.Powerups.Maxed = MaxedPowerups{}
+	maxed := reflect.ValueOf(&user.Powerups.Maxed).Elem()
+
+	for _, powerup := range game.Config.Powerups {
+		stat := reflect.ValueOf(user.Powerups).FieldByName(powerup.Stat)
+		field := maxed.FieldByName(powerup.Stat)
+		if stat.Kind() != reflect.Int || !field.IsValid() {
+			continue
+		}
+		if powerup.Maxed(int(stat.Int()) + game.StatOffset(user, powerup.Stat)) {
+			field.SetBool(true)
+		}
+	}
+}
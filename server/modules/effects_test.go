@@ -0,0 +1,133 @@
+package modules
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePowerups(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		err  string // part of the error message, empty if the powerups are valid
+	}{
+		{"valid", `[{"Name": "Speed", "Icon": 9, "Stat": "Speed", "Step": 2}, {"Name": "Remote", "Icon": 12, "Stat": "BombType", "Value": "remote"}]`, ""},
+		{"curse", `[{"Name": "Skull", "Icon": 15, "Stat": "Curse", "Duration": 10}]`, ""},
+		{"unknown stat", `[{"Name": "Armor", "Icon": 9, "Stat": "Armor", "Step": 1}]`, "unknown stat 'Armor'"},
+		{"stat that isn't a number or text", `[{"Name": "Max", "Icon": 9, "Stat": "Maxed", "Step": 1}]`, "unknown stat 'Maxed'"},
+		{"no name", `[{"Icon": 9, "Stat": "Speed", "Step": 1}]`, "has no name"},
+		{"icon of a grid block", `[{"Name": "Speed", "Icon": 3, "Stat": "Speed", "Step": 1}]`, "are grid blocks"},
+		{"icon used twice", `[{"Name": "Speed", "Icon": 9, "Stat": "Speed"}, {"Name": "Flame", "Icon": 9, "Stat": "Flame"}]`, "belongs to 'Speed'"},
+		{"bad json", `[{"Name": "Speed", "Icon": 9,`, "unexpected end"},
+		{"wrong type", `{"Name": "Speed"}`, "cannot unmarshal"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			powerups, err := ParsePowerups([]byte(test.json))
+			if test.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if _, ok := powerups["Nothing"]; !ok {
+					t.Error("powerups have no empty barrels")
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("error = %v, want one containing %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestTimedEffects(t *testing.T) {
+	speed := Powerup{Name: "Speed", Stat: "Speed", Step: 2, Max: 10, Duration: 0.5}
+	remote := Powerup{Name: "Remote", Stat: "BombType", Value: "remote", Duration: 1}
+	pierce := Powerup{Name: "Pierce", Stat: "BombType", Value: "pierce", Duration: 0.5}
+
+	type pickup struct {
+		tick    int
+		powerup Powerup
+	}
+
+	tests := []struct {
+		name    string
+		stat    string
+		pickups []pickup
+		want    map[int]string // value of the stat at the end of the tick
+	}{
+		{
+			name:    "integer stat",
+			stat:    "Speed",
+			pickups: []pickup{{1, speed}},
+			want:    map[int]string{1: "6", 10: "6", 11: "4"},
+		},
+		{
+			name:    "overlapping integer stats",
+			stat:    "Speed",
+			pickups: []pickup{{1, speed}, {5, speed}},
+			want:    map[int]string{5: "8", 11: "6", 15: "4"},
+		},
+		{
+			name:    "text stat",
+			stat:    "BombType",
+			pickups: []pickup{{1, pierce}},
+			want:    map[int]string{1: "pierce", 10: "pierce", 11: ""},
+		},
+		{
+			name:    "overlapping text stats, first one expires first",
+			stat:    "BombType",
+			pickups: []pickup{{1, pierce}, {5, remote}},
+			want:    map[int]string{5: "remote", 11: "remote", 25: ""},
+		},
+		{
+			name:    "overlapping text stats, last one expires first",
+			stat:    "BombType",
+			pickups: []pickup{{1, remote}, {5, pierce}},
+			want:    map[int]string{5: "pierce", 15: "remote", 21: ""},
+		},
+		{
+			name:    "overlapping text stats expire on the same tick",
+			stat:    "BombType",
+			pickups: []pickup{{1, pierce}, {1, remote}, {11, pierce}},
+			want:    map[int]string{1: "remote", 11: "pierce", 21: ""},
+		},
+		{
+			name:    "permanent text stat",
+			stat:    "BombType",
+			pickups: []pickup{{1, Powerup{Name: "Remote", Stat: "BombType", Value: "remote"}}},
+			want:    map[int]string{1: "remote", 40: "remote"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			game, users := newTestGame(t, 2)
+			user := users[0]
+
+			for tick := 1; tick <= 40; tick++ {
+				game.Tick = tick
+				game.UpdateEffects()
+				for _, pickup := range test.pickups {
+					if pickup.tick == tick {
+						game.ApplyPowerup(user, pickup.powerup)
+					}
+				}
+
+				want, ok := test.want[tick]
+				if !ok {
+					continue
+				}
+				if got := fmt.Sprint(reflect.ValueOf(user.Powerups).FieldByName(test.stat).Interface()); got != want {
+					t.Errorf("tick %d: %s = %q, want %q", tick, test.stat, got, want)
+				}
+			}
+			if len(user.Effects) != 0 && test.want[40] == "" {
+				t.Errorf("%d effects left after they all expired", len(user.Effects))
+			}
+		})
+	}
+}
@@ -2,8 +2,8 @@ package modules
 
 import (
 	"bomberman_dom/server/logger"
-	"errors"
 	"fmt"
+	"sync"
 	"time"
 )
 
@@ -20,26 +20,59 @@ type Game struct {
 	GameId           GameId
 	Status           GameStatus
 	Players          map[UserId]*User
+	Spectators       map[UserId]*User
 	Grid             Grid
 	ActivePowerUps   Grid
 	Config           GameConfig
 	BarrelsBroken    int
 	BarrelContents   []PowerupName
 	ActiveExplosions Grid
+	Tick             int
+	HostId           UserId         // Player who can change the lobby settings, empty for matchmaking games
+	Round            int            // Round of the series being played, 0 in the lobby
+	RoundWins        map[UserId]int // Rounds won by each player in the series
+
+	bombs          []*Bomb
+	bombTiles      map[Position]*Bomb
+	barrelPowerups map[Position]PowerupName // Powerups of the barrels with weighted drops
+	brokenBarrels  map[Position]bool        // Barrels hit by an explosion of the round, they are emptied when the explosion ends
+	explosions     []*ActiveExplosion
+	shrinkOrder    []Position
+	shrinkSchedule []int
+	shrunkTiles    int
+	history        map[int]*StateSnapshot
+	events         []Envelope
+	inputs         []Input
+	inputsMut      *sync.Mutex
+	mut            *sync.Mutex // Guards the game and the users in it, see LockGames
+	leavers        []User // Players who left the running round, they are rated last in its match
+	limiters       map[UserId]*inputLimiter
+	replay         *Replay
+	scoreboard     []ScoreEntry
+	suddenDeath    bool
 }
 
 // GameConfig contains variables which affect the game that will be created
 type GameConfig struct {
-	Powerups      map[PowerupName]Powerup
-	GridConfig    GridConfig
-	GameId        GameId
-	CharacterSize int
-	Lives         int
+	Powerups         map[PowerupName]Powerup
+	GridConfig       GridConfig
+	GameId           GameId
+	CharacterSize    int
+	Lives            int
+	TickRate         int // Game ticks per second
+	KeyframeInterval int // How many ticks to wait between sending full state keyframes
+	InputLimits      InputLimits
+	DropPowerups     bool // Eliminated players scatter the powerups they collected onto empty tiles
+	DestroyPowerups  bool // Explosions destroy the powerups lying on empty tiles
+	DropMode         DropMode
+	Rounds           int  // Rounds of a best-of series, the lobby stays together between them
+	TeamMode         bool // Players are split into teams, the last team standing wins
+	FriendlyFire     bool // Explosions take lives from teammates of the bomb owner in team mode
+	Timing           TimingProfile
 }
 
 // ReadyToPlay checks and sends back message about lobby player ready state
-func ReadyToPlay(data Data) {
-	user := GlobalClients.GetUser(UserId(data.UserId))
+func ReadyToPlay(user *User) {
 	game := GlobalGames.GetGame(GameId(user.GameId))
 	message := "All players are ready to play"
 
@@ -51,13 +84,14 @@ func ReadyToPlay(data Data) {
 
 	if len(game.Players) == 1 {
 		message = "You need one more player to start the game!"
+	} else if game.Config.TeamMode && len(game.Teams()) < teamCount {
+		message = "Every team needs a player to start the game!"
 	}
 
-	sendData := Data{
-		Type:       "userToggleReady",
+	sendData := ReadyState{
 		Username:   user.Username,
-		GameId:     string(game.GameId),
-		UserId:     string(user.UserId),
+		GameId:     game.GameId,
+		UserId:     user.UserId,
 		ReadyState: user.ReadyState,
 		Message:    message,
 		Color:      user.Color,
@@ -68,66 +102,112 @@ func ReadyToPlay(data Data) {
 }
 
 // ToggleUserReady Changes user ready state to opposite
-func ToggleUserReady(data Data) {
-	user := GlobalClients.GetUser(UserId(data.UserId))
+func ToggleUserReady(user *User) {
 	user.ReadyState = !user.ReadyState
 }
 
-// StartGame Set player positions, starts game timer
-func StartGame(data Data) {
-	user := GlobalClients.GetUser(UserId(data.UserId))
+// StartGame Set player positions, starts game loop
+func StartGame(user *User) {
 	game := GlobalGames.GetGame(GameId(user.GameId))
+	if game.GameId == "global" {
+		sendLobbyError(user, "Games can't be started from the global chat!")
+		return
+	}
 	if game.Status == InGame {
 		logger.Log("WTF")
 		return
 	}
+	// the result of the last round is still shown
+	if game.Status == GameEnded {
+		return
+	}
+	if game.Config.TeamMode && len(game.Teams()) < teamCount {
+		sendLobbyError(user, "Every team needs a player to start the game!")
+		return
+	}
 
+	game.BeginRound()
+	go game.GameLoop(game.Round)
+}
+
+// BeginRound resets the round state, puts the players onto their spawn points and sends them the game info
+func (game *Game) BeginRound() {
 	game.Status = InGame
+	game.Round++
+	game.Tick = 0
+	game.shrinkOrder = game.ShrinkGridOrder()
+	game.shrinkSchedule = game.ShrinkSchedule()
+	game.history = make(map[int]*StateSnapshot)
+	game.limiters = make(map[UserId]*inputLimiter)
+	game.leavers = nil
+	game.brokenBarrels = make(map[Position]bool)
+	game.scoreboard = nil
+	game.suddenDeath = false
 	// set all users positions
 	game.SetPlayerPositions()
+	game.replay = game.NewReplay()
+	// previously acknowledged states belong to other games
+	for _, player := range GlobalGames.ListGamePlayers(game.GameId) {
+		player.Conn.Ack(0)
+	}
 
 	// Send back game info, and end time of the game
-	sendData := Data{
-		Type:     "startGame",
+	sendData := GameStarted{
 		GameInfo: game.PrepareForSend(),
-		Date:     time.Now().Add(time.Minute * 3).Format("2006-01-02 15:04:05"),
+		Date:     game.EndDate(),
 	}
 
 	err := GlobalGames.BroadcastToGame(game.GameId, sendData)
 	HandleError(err)
+}
 
-	GameTimer(game)
+// EndDate returns the time when the game ends at the latest, counted from the current tick
+func (game *Game) EndDate() string {
+	remaining := time.Duration(game.Ticks(game.Config.Timing.MatchDuration)-game.Tick) * time.Second / time.Duration(game.Config.TickRate)
+	return time.Now().Add(remaining).Format("2006-01-02 15:04:05")
 }
 
-// GameTimer Checks timer, skrinks map after specific time, updates grid with wall blocks, removes player lifes, if caught in the wall 
-func GameTimer(game *Game) {
-	shrinkOrder := game.ShrinkGridOrder()
+// ShrinkSchedule returns the tick on which each tile of the ShrinkGridOrder is changed to a wall block
+func (game *Game) ShrinkSchedule() []int {
 	innerArea := (game.Config.GridConfig.Width - 6) * (game.Config.GridConfig.Height - 6)
-	outerCirclesTileAmount := (game.Config.GridConfig.Width-2)*(game.Config.GridConfig.Height-2) - innerArea
-
-	gridShrinkTime := 90 * time.Second
-	gameEndTime := 3 * time.Minute
-	endShrinkTime := gameEndTime - time.Duration((innerArea*50))*time.Millisecond
+	outerCirclesTileAmount := game.OuterCirclesTileAmount()
+	timing := game.Config.Timing
+	endShrinkTime := timing.MatchDuration - time.Duration(innerArea)*timing.InnerShrinkStep
 
+	var schedule []int
 	// Outer 2 circle shrink
-	go func() {
-		time.Sleep(gridShrinkTime)
-		timeToWait := (30 * time.Second) / time.Duration(outerCirclesTileAmount)
-		for i := 0; i < outerCirclesTileAmount; i++ {
-			if !GlobalGames.Exists(game.GameId) {
-				return
-			}
-			// Change tile to wall Block
-			var X, Y = shrinkOrder[i].X, shrinkOrder[i].Y
-			game.Grid[Y][X] = game.Config.GridConfig.WallBlock
-			game.ActivePowerUps[Y][X] = game.Config.GridConfig.EmptyBlock
+	timeToWait := timing.OuterShrinkDuration / time.Duration(outerCirclesTileAmount)
+	for i := 0; i < outerCirclesTileAmount; i++ {
+		schedule = append(schedule, game.Ticks(timing.ShrinkStart+time.Duration(i)*timeToWait))
+	}
+	// Inner area shrink
+	for i := 0; i < len(game.shrinkOrder)-outerCirclesTileAmount; i++ {
+		schedule = append(schedule, game.Ticks(endShrinkTime+time.Duration(i)*timing.InnerShrinkStep))
+	}
+
+	return schedule
+}
+
+// OuterCirclesTileAmount returns the amount of tiles in the 2 outer circles of the grid which can be walked on
+func (game *Game) OuterCirclesTileAmount() int {
+	innerArea := (game.Config.GridConfig.Width - 6) * (game.Config.GridConfig.Height - 6)
 
-			// Send new game map to players
-			err := GlobalGames.BroadcastToGame(game.GameId, Data{
-				Type:     "shrinkMap",
-				GameInfo: game.PrepareForSend(),
-			})
-			HandleError(err)
+	return (game.Config.GridConfig.Width-2)*(game.Config.GridConfig.Height-2) - innerArea
+}
+
+// UpdateShrink changes the tiles which are due on the current tick to wall blocks, players caught in the outer circle walls lose all lives
+func (game *Game) UpdateShrink() {
+	outerCirclesTileAmount := game.OuterCirclesTileAmount()
+	shrunk := false
+
+	for game.shrunkTiles < len(game.shrinkOrder) && game.shrinkSchedule[game.shrunkTiles] <= game.Tick {
+		// Change tile to wall Block
+		var X, Y = game.shrinkOrder[game.shrunkTiles].X, game.shrinkOrder[game.shrunkTiles].Y
+		game.Grid[Y][X] = game.Config.GridConfig.WallBlock
+		shrunk = true
+
+		if game.shrunkTiles < outerCirclesTileAmount {
+			game.ActivePowerUps[Y][X] = game.Config.GridConfig.EmptyBlock
 
 			var tileSize = game.Config.GridConfig.Tilesize
 			var userSize = game.Config.CharacterSize
@@ -139,110 +219,106 @@ func GameTimer(game *Game) {
 				if userX+userSize > tileX && userX < tileX+tileSize &&
 					userY+userSize > tileY && userY < tileY+tileSize {
 					// Lose all lives
-					game.LoseLife(GlobalClients.GetUser(user.UserId), game.Config.Lives)
+					game.LoseLife(GlobalClients.GetUser(user.UserId), game.Config.Lives, "")
 				}
 			}
-
-			time.Sleep(timeToWait)
 		}
-	}()
-	
-	go func() {
-		time.Sleep(endShrinkTime)
-		for i := outerCirclesTileAmount; i < len(shrinkOrder); i++ {
-			if !GlobalGames.Exists(game.GameId) {
-				return
-			}
-
-			// Change tile to wall Block
-			var X, Y = shrinkOrder[i].X, shrinkOrder[i].Y
-			game.Grid[Y][X] = game.Config.GridConfig.WallBlock
-
-			// Send new game map to players
-			err := GlobalGames.BroadcastToGame(game.GameId, Data{
-				Type:     "shrinkMap",
-				GameInfo: game.PrepareForSend(),
-			})
-			HandleError(err)
+		game.shrunkTiles++
+	}
 
-			time.Sleep(50 * time.Millisecond)
-		}
-		GameOver(game.GameId)
-	}()
+	if shrunk {
+		// Send new game map to players
+		game.Emit(ShrinkMapEvent{})
+	}
 }
 
 // NewGameConfigs returns a GameConfig filled with default values
 func NewGameConfig() GameConfig {
 	return GameConfig{
-		Powerups: map[PowerupName]Powerup{
-			"Bomb": {
-				Name:   "Bomb",
-				Amount: 5,
-				Icon:   7,
-			},
-			"Flame": {
-				Name:   "Flame",
-				Amount: 5,
-				Icon:   8,
-			},
-			"Speed": {
-				Name:   "Speed",
-				Amount: 5,
-				Icon:   9,
-			},
-			"Nothing": {
-				Name: "Nothing",
-				Icon: 0,
-			},
-		},
-		GridConfig:    NewGridConfig(),
+		Powerups:      CopyPowerups(PowerupDefinitions),
+		GridConfig:    NewGridConfig(defaultPlayers),
 		GameId:        GameId(RandCode()),
 		Lives:         3,
 		CharacterSize: 35,
+		TickRate:         20,
+		KeyframeInterval: 100,
+		InputLimits:      NewInputLimits(),
+		DropPowerups:     true,
+		DestroyPowerups:  true,
+		DropMode:         FixedDrops,
+		Timing:           TimingProfiles["classic"],
+		Rounds:           1,
 	}
 }
 
 // NewGame returns a new Game instance based on the GameConfig provided
 func NewGame(config GameConfig) Game {
-	return Game{
+	game := Game{
 		GameId:           config.GameId,
 		Status:           InLobby,
 		Grid:             config.GridConfig.NewGrid(),
 		ActivePowerUps:   config.GridConfig.NewEmptyGrid(),
 		Config:           config,
 		BarrelsBroken:    0,
-		BarrelContents:   config.GetRandomPowerups(),
 		Players:          make(map[UserId]*User),
+		Spectators:       make(map[UserId]*User),
 		ActiveExplosions: config.GridConfig.NewEmptyGrid(),
+		inputsMut:        &sync.Mutex{},
+		mut:              &sync.Mutex{},
+		bombTiles:        make(map[Position]*Bomb),
+		RoundWins:        make(map[UserId]int),
+	}
+
+	if config.DropMode == WeightedDrops || config.DropMode == FairDrops {
+		game.barrelPowerups = config.GetWeightedPowerups(game.Grid)
+	} else {
+		game.BarrelContents = config.GetRandomPowerups(game.Grid)
 	}
+
+	return game
 }
 
-// SetPlayerPositions sets all the players coordinates
+// SetPlayerPositions sets all the players coordinates to the spawn points of the grid
 func (game Game) SetPlayerPositions() {
 	const padding = 6
 	var tileSize = game.Config.GridConfig.Tilesize
+	var spawns = game.Config.GridConfig.SpawnPoints()
 
 	for index, usr := range GlobalGames.ListGamePlayers(game.GameId) {
 		user := GlobalClients.GetUser(usr.UserId)
-		var position = Position{
-			X: tileSize + padding,
-			Y: tileSize + padding,
+		spawn := spawns[index%len(spawns)]
+		user.Position = Position{
+			X: spawn.X*tileSize + padding,
+			Y: spawn.Y*tileSize + padding,
 		}
+	}
+}
+
+// Player returns the player of the game with the id, nil if the user doesn't play in the game
+func (game *Game) Player(userId UserId) *User {
+	return game.Players[userId]
+}
 
-		switch index {
-		case 1:
-			position.X += (game.Config.GridConfig.Width - 3) * tileSize
-			position.Y += (game.Config.GridConfig.Height - 3) * tileSize
-		case 2:
-			position.Y += (game.Config.GridConfig.Height - 3) * tileSize
-		case 3:
-			position.X += (game.Config.GridConfig.Width - 3) * tileSize
+// Participant returns the player with the id, players who have left the running round are returned from their record in the match.
+// Returns nil if the user didn't take part in the round.
+func (game *Game) Participant(userId UserId) *User {
+	if player := game.Player(userId); player != nil {
+		return player
+	}
+	for i := range game.leavers {
+		if game.leavers[i].UserId == userId {
+			return &game.leavers[i]
 		}
-		user.Position = position
 	}
+	return nil
+}
+
+// MaxPlayers returns how many players fit in the game
+func (config GameConfig) MaxPlayers() int {
+	return config.GridConfig.Players
 }
 
-// GameOver Gets winner, dissambles lobby and add all players to new lobby
+// GameOver Gets winner, adds the round to the series tally and starts the next round or returns everyone to the lobby
 func GameOver(gameId GameId) {
 	game := GlobalGames.GetGame(gameId)
 
@@ -251,55 +327,70 @@ func GameOver(gameId GameId) {
 	}
 	game.Status = GameEnded
 
-	winners, err := game.GetWinner()
-	if err != nil {
-		logger.Error(err)
-		return
-	}
+	winners := game.GetWinner()
 
-	gameResult := "win"
-	if len(winners) > 1 {
-		gameResult = "tie"
+	// a round without survivors is a tie without winners
+	gameResult := "tie"
+	winningTeam := 0
+	winningTeams := make(map[string]bool)
+	for _, winner := range winners {
+		winningTeams[winner.TeamKey()] = true
+	}
+	if len(winningTeams) == 1 {
+		gameResult = "win"
+		winningTeam = winners[0].Team
+	}
+	logger.Log(fmt.Sprintf("Game '%s' over, result %s with %d winners", game.GameId, gameResult, len(winners)))
+	seriesOver := game.RecordRound(gameResult, winners)
+	result := GameResult{
+		Result:     gameResult,
+		Winners:    winners,
+		Team:       winningTeam,
+		GameInfo:   game.PrepareForSend(),
+		Scoreboard: game.Scoreboard(),
+		Series:     game.SeriesState(seriesOver),
 	}
-	logger.Log(fmt.Sprintf("Game '%s' over, result %s by %s", game.GameId, gameResult, winners[0].Username))
 	// Send message "GameEnd" with winner
-	err = GlobalGames.BroadcastToGame(gameId, Data{
-		Type:     "gameOver",
-		Message:  gameResult,
-		Users:    winners,
-		GameInfo: game.PrepareForSend(),
-	})
+	err := GlobalGames.BroadcastToGame(gameId, result)
 	HandleError(err)
 
-	go func() {
-		// Wait 5 seconds, then create new game and make all players join that one
-		time.Sleep(5 * time.Second)
+	game.replay.Result = &result
+	go game.replay.Save()
+	go RecordMatch(game, result, append([]User{}, game.leavers...))
+
+	go func(wait time.Duration) {
+		// Wait for the players to see the result, then play the next round or return to the lobby
+		time.Sleep(wait)
+		unlock := LockGames(game.GameId, "global")
+		defer unlock()
 		if !GlobalGames.Exists(game.GameId) {
 			return
 		}
 
-		newConfig := NewGameConfig()
-		newGame := NewGame(newConfig)
-		GlobalGames.Add(&newGame)
-
-		for _, player := range GlobalGames.ListGamePlayers(game.GameId) {
-			// Leave current lobby
-			LeaveLobby(Data{GameId: string(game.GameId), UserId: string(player.UserId)})
-			GlobalClients.GetUser(player.UserId).ReadyState = false
-			// Join new lobby
-			JoinLobby(Data{GameId: string(newGame.GameId), UserId: string(player.UserId)})
+		if seriesOver {
+			game.ReturnToLobby()
+		} else {
+			game.NextRound()
 		}
-	}()
+	}(roundBreak)
 }
 
-// GetWinner Gets last standing players in game
-func (game *Game) GetWinner() (winners []User, err error) {
+// GetWinner Gets last standing players in game, in team mode the whole team of a last standing player wins.
+// There are no winners when the last players were eliminated at the same time.
+func (game *Game) GetWinner() (winners []User) {
 	alive := game.AlivePlayers()
-	if len(alive) == 0 {
-		return winners, errors.New("no alive players left")
+	if !game.Config.TeamMode || len(alive) == 0 {
+		return alive
 	}
 
-	return alive, nil
+	aliveTeams := game.AliveTeams()
+	for _, player := range GlobalGames.ListGamePlayers(game.GameId) {
+		if aliveTeams[player.TeamKey()] {
+			winners = append(winners, player)
+		}
+	}
+
+	return winners
 }
 
 // AlivePlayers List of all alive players in the game and their data 
@@ -318,7 +409,16 @@ func (game *Game) PrepareForSend() Game {
 	var safeGame = Game{
 		GameId:  game.GameId,
 		Status:  game.Status,
-		Players: game.Players,
+		Players: make(map[UserId]*User),
+		Tick:    game.Tick,
+		Config:  game.Config,
+		Round:   game.Round,
+	}
+
+	// copy players so the snapshot doesn't change while it's being sent
+	for _, player := range GlobalGames.ListGamePlayers(game.GameId) {
+		player := player
+		safeGame.Players[player.UserId] = &player
 	}
 
 	for index, row := range game.Grid {
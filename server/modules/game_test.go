@@ -0,0 +1,69 @@
+package modules
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestShrinkSchedule(t *testing.T) {
+	tests := []struct {
+		timing        string
+		width, height int
+	}{
+		{"blitz", 15, 13},
+		{"classic", 15, 13},
+		{"classic", 9, 9},
+		{"classic", 25, 21},
+		{"endurance", 19, 17},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s %dx%d", test.timing, test.width, test.height), func(t *testing.T) {
+			game := NewGame(NewGameConfig())
+			game.Config.Timing = TimingProfiles[test.timing]
+			game.Config.GridConfig.Width, game.Config.GridConfig.Height = test.width, test.height
+			game.shrinkOrder = game.ShrinkGridOrder()
+			timing := game.Config.Timing
+
+			schedule := game.ShrinkSchedule()
+			if len(schedule) != len(game.shrinkOrder) {
+				t.Fatalf("%d scheduled tiles, want %d", len(schedule), len(game.shrinkOrder))
+			}
+			if schedule[0] != game.Ticks(timing.ShrinkStart) {
+				t.Errorf("shrinking starts on tick %d, want %d", schedule[0], game.Ticks(timing.ShrinkStart))
+			}
+			outer := game.OuterCirclesTileAmount()
+			if last := schedule[outer-1]; last > game.Ticks(timing.ShrinkStart+timing.OuterShrinkDuration) {
+				t.Errorf("outer circles shrink until tick %d, want at most %d", last, game.Ticks(timing.ShrinkStart+timing.OuterShrinkDuration))
+			}
+			if last := schedule[len(schedule)-1]; last > game.Ticks(timing.MatchDuration) {
+				t.Errorf("last tile shrinks on tick %d after the match ends on %d", last, game.Ticks(timing.MatchDuration))
+			}
+			for i := 1; i < len(schedule); i++ {
+				if schedule[i] < schedule[i-1] {
+					t.Fatalf("tile %d shrinks on tick %d before tile %d on tick %d", i, schedule[i], i-1, schedule[i-1])
+				}
+			}
+		})
+	}
+}
+
+func TestGlobalChatCantStartGames(t *testing.T) {
+	global := GlobalGames.GetGame("global")
+	user := newTestUser(t, "player")
+	JoinLobby(user, "global")
+
+	StartGame(user)
+	if global.Status != InLobby {
+		t.Fatalf("global chat status = %v, want InLobby", global.Status)
+	}
+
+	// users joining the global chat are never spectators
+	global.Status = InGame
+	t.Cleanup(func() { global.Status = InLobby })
+	other := newTestUser(t, "other")
+	JoinLobby(other, "global")
+	if other.Spectator || !GlobalGames.PlayerExists("global", other.UserId) {
+		t.Errorf("user joined the global chat as a spectator")
+	}
+}
@@ -57,7 +57,7 @@ func (gg *globalGames) ListGamePlayers(gameId GameId) []User {
 	defer gg.RUnlock()
 	out := []User{}
 
-	game := gg.GetGame(gameId)
+	game := gg.Data[gameId]
 
 	for _, user := range game.Players {
 		out = append(out, *user)
@@ -66,10 +66,56 @@ func (gg *globalGames) ListGamePlayers(gameId GameId) []User {
 	return out
 }
 
-// BroadcastToGame sends data to all game players
-func (gg *globalGames) BroadcastToGame(gameId GameId, data Data) error {
+// ListGameSpectators returns a list of users watching the game specified by the provided GameId
+func (gg *globalGames) ListGameSpectators(gameId GameId) []User {
+	gg.RLock()
+	defer gg.RUnlock()
+	out := []User{}
+
+	game := gg.Data[gameId]
+
+	for _, user := range game.Spectators {
+		out = append(out, *user)
+	}
+
+	return out
+}
+
+// UserGame returns the id of the game the user is in
+func (gg *globalGames) UserGame(user *User) GameId {
+	gg.RLock()
+	defer gg.RUnlock()
+	return GameId(user.GameId)
+}
+
+// SetUserGame moves the user to the game, the locks of the game the user is in and of the new game have to be held
+func (gg *globalGames) SetUserGame(user *User, gameId GameId) {
+	gg.Lock()
+	defer gg.Unlock()
+	user.GameId = string(gameId)
+}
+
+// BroadcastToGame sends a message to all game players and spectators
+func (gg *globalGames) BroadcastToGame(gameId GameId, msg Message) error {
 	for _, client := range gg.ListGamePlayers(gameId) {
-		client.Conn.Send(data)
+		client.Conn.Send(msg)
+	}
+	for _, client := range gg.ListGameSpectators(gameId) {
+		client.Conn.Send(msg)
+	}
+
+	return nil
+}
+
+// BroadcastToSpectators sends a message to everyone spectating the game, including eliminated players
+func (gg *globalGames) BroadcastToSpectators(gameId GameId, msg Message) error {
+	for _, client := range gg.ListGamePlayers(gameId) {
+		if client.Spectator {
+			client.Conn.Send(msg)
+		}
+	}
+	for _, client := range gg.ListGameSpectators(gameId) {
+		client.Conn.Send(msg)
 	}
 
 	return nil
@@ -95,6 +141,24 @@ func (gg *globalGames) RemovePlayer(gameId GameId, cid UserId) {
 	delete(gg.Data[gameId].Players, cid)
 }
 
+// AddSpectator adds a spectator to the game specified by the GameId provided
+func (gg *globalGames) AddSpectator(gameId GameId, user *User) {
+	gg.Lock()
+	defer gg.Unlock()
+	gg.Data[gameId].Spectators[user.UserId] = user
+}
+
+// RemoveSpectator removes a spectator from the game, returns false if the user wasn't spectating it
+func (gg *globalGames) RemoveSpectator(gameId GameId, cid UserId) bool {
+	gg.Lock()
+	defer gg.Unlock()
+
+	_, ok := gg.Data[gameId].Spectators[cid]
+	delete(gg.Data[gameId].Spectators, cid)
+
+	return ok
+}
+
 // Exists returns a boolean indicating wheteher a game with a given GameId exists
 func (gg *globalGames) Exists(gameId GameId) bool {
 	gg.RLock()
@@ -115,11 +179,11 @@ func (gg *globalGames) PlayerExists(gameId GameId, cid UserId) bool {
 	return ok
 }
 
-// BroadcastToOtherGamePlayers send data to other game players
-func (gg *globalGames) BroadcastToOtherGamePlayers(gameId GameId, cid UserId, data Data) error {
+// BroadcastToOtherGamePlayers send a message to other game players
+func (gg *globalGames) BroadcastToOtherGamePlayers(gameId GameId, cid UserId, msg Message) error {
 	for _, client := range gg.ListGamePlayers(gameId) {
 		if client.UserId != cid {
-			client.Conn.Send(data)
+			client.Conn.Send(msg)
 		}
 	}
 	
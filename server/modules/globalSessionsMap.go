@@ -0,0 +1,42 @@
+package modules
+
+import (
+	"sync"
+	"time"
+)
+
+// GlobalSessions stores the resumable sessions of authenticated users by their session token
+var GlobalSessions = globalSessions{Data: make(map[string]*Session), RWMutex: &sync.RWMutex{}}
+
+type globalSessions struct {
+	Data map[string]*Session
+	*sync.RWMutex
+}
+
+// Session belongs to an authenticated user, a user whose connection dropped can resume it during the grace period
+type Session struct {
+	UserId UserId
+	expiry *time.Timer // set while the user is disconnected
+}
+
+// Add adds a new session to the map
+func (gs *globalSessions) Add(token string, session *Session) {
+	gs.Lock()
+	defer gs.Unlock()
+	gs.Data[token] = session
+}
+
+// Del removes a session from the map
+func (gs *globalSessions) Del(token string) {
+	gs.Lock()
+	defer gs.Unlock()
+	delete(gs.Data, token)
+}
+
+// GetSession returns a pointer to a session specified by the token provided
+func (gs *globalSessions) GetSession(token string) *Session {
+	gs.RLock()
+	defer gs.RUnlock()
+
+	return gs.Data[token]
+}
@@ -16,24 +16,98 @@ type GridConfig struct {
 	BarrelBlock    int
 	FillPercentage float64
 	CornerArea     int // How many blocks to leave empty next to the corner - Will be the same vertically & horizontally. Max is (shorter side - 5) / 2
+	Players        int // How many players the grid has spawn points for, the player capacity of the game
 }
 
 type Grid [][]int
 
-// NewGridConfig returns a GridConfig filled with default values
-func NewGridConfig() GridConfig {
+// NewGridConfig returns a GridConfig filled with default values, the grid size grows with the player capacity
+func NewGridConfig(players int) GridConfig {
+	width, height := DefaultGridSize(players)
 	return GridConfig{
-		Width:          15,
-		Height:         13,
+		Width:          width,
+		Height:         height,
 		Tilesize:       44,
 		EmptyBlock:     0,
 		WallBlock:      1,
 		BarrelBlock:    2,
 		FillPercentage: 0.8,
 		CornerArea:     1,
+		Players:        players,
 	}
 }
 
+// DefaultGridSize returns the default width and height of the grid for the player capacity
+func DefaultGridSize(players int) (width, height int) {
+	switch {
+	case players <= 4:
+		return 15, 13
+	case players <= 6:
+		return 17, 15
+	default:
+		return 19, 17
+	}
+}
+
+// SpawnPoints returns the tiles the players start on, one for each player the grid is made for.
+// The first four are the corners, more players start in the middle of the outer rows and columns.
+// The middle tiles are odd, so the tile next to them towards the center isn't a wall.
+func (config GridConfig) SpawnPoints() []Position {
+	right, bottom := config.Width-2, config.Height-2
+	middleX, middleY := config.Width/2, config.Height/2
+	if middleX%2 == 0 {
+		middleX--
+	}
+	if middleY%2 == 0 {
+		middleY--
+	}
+
+	spawns := []Position{
+		{1, 1},
+		{right, bottom},
+		{1, bottom},
+		{right, 1},
+		{middleX, 1},
+		{middleX, bottom},
+		{1, middleY},
+		{right, middleY},
+	}
+	if config.Players < len(spawns) {
+		spawns = spawns[:config.Players]
+	}
+	return spawns
+}
+
+// SpawnAreas returns the tiles kept empty around the spawn points in the middle of the outer rows and columns,
+// the tiles on both sides and the one towards the center leave room to escape a bomb. The corner spawn points are kept empty by CornerArea.
+func (config GridConfig) SpawnAreas() map[Position]bool {
+	area := make(map[Position]bool)
+	spawns := config.SpawnPoints()
+	if len(spawns) <= 4 {
+		return area
+	}
+
+	for _, spawn := range spawns[4:] {
+		var x, y = spawn.X, spawn.Y
+		var tiles []Position
+		switch {
+		case y == 1:
+			tiles = []Position{{x - 1, y}, {x + 1, y}, {x, y + 1}}
+		case y == config.Height-2:
+			tiles = []Position{{x - 1, y}, {x + 1, y}, {x, y - 1}}
+		case x == 1:
+			tiles = []Position{{x, y - 1}, {x, y + 1}, {x + 1, y}}
+		default:
+			tiles = []Position{{x, y - 1}, {x, y + 1}, {x - 1, y}}
+		}
+
+		for _, tile := range append(tiles, spawn) {
+			area[tile] = true
+		}
+	}
+	return area
+}
+
 // NewGrid returns a new Grid instance based on the GridConfig provided
 func (config GridConfig) NewGrid() Grid {
 	var grid = config.NewEmptyGrid()
@@ -83,20 +157,27 @@ func (grid Grid) GetRandomBarrels(config GridConfig) []int {
 // PlaceBarrels fills the appropriate squares of the grid with randomly generated barrels
 func (grid Grid) PlaceBarrels(config GridConfig) Grid {
 	randomOrder := grid.GetRandomBarrels(config)
+	spawnAreas := config.SpawnAreas()
 	var index = 0
+	// fill places the next random block on the tile, the spawn areas stay empty
+	fill := func(x, y int) {
+		if spawnAreas[Position{x, y}] {
+			return
+		}
+		grid[y][x] = randomOrder[index]
+		index++
+	}
 
 	// fill all rows in the center area which don't have walls
 	for y := 3; y < config.Height-2; y += 2 {
 		for x := 2; x < config.Width-2; x++ {
-			grid[y][x] = randomOrder[index]
-			index++
+			fill(x, y)
 		}
 	}
 	// fill all rows in the center area which have walls
 	for y := 2; y < config.Height-2; y += 2 {
 		for x := 3; x < config.Width-2; x += 2 {
-			grid[y][x] = randomOrder[index]
-			index++
+			fill(x, y)
 		}
 	}
 
@@ -111,10 +192,8 @@ func (grid Grid) PlaceBarrels(config GridConfig) Grid {
 			grid[config.Height-2][x] = config.BarrelBlock
 			continue
 		}
-		grid[1][x] = randomOrder[index]
-		index++
-		grid[config.Height-2][x] = randomOrder[index]
-		index++
+		fill(x, 1)
+		fill(x, config.Height-2)
 	}
 
 	// fill first and last column
@@ -124,10 +203,8 @@ func (grid Grid) PlaceBarrels(config GridConfig) Grid {
 			grid[y][config.Width-2] = config.BarrelBlock
 			continue
 		}
-		grid[y][1] = randomOrder[index]
-		index++
-		grid[y][config.Width-2] = randomOrder[index]
-		index++
+		fill(1, y)
+		fill(config.Width-2, y)
 	}
 
 	return grid
@@ -160,13 +237,32 @@ func (grid Grid) PlaceWalls(config GridConfig) Grid {
 	return grid
 }
 
-// Calculates available space where barrels could be placed, excludes corner areas.
+// Calculates available space where barrels could be placed, excludes corner areas and spawn areas.
 func (config GridConfig) GetEmptySpaces() int {
 	var gridNoSurroundingWalls = (config.Width - 2) * (config.Height - 2)
 	var CornerArea = (1 + 2*(config.CornerArea + 1)) * 4
 	var filledWalls = ((config.Width-4)/2 + 1) * ((config.Height-4)/2 + 1)
 
-	return gridNoSurroundingWalls - CornerArea - filledWalls
+	return gridNoSurroundingWalls - CornerArea - filledWalls - len(config.SpawnAreas())
+}
+
+// BarrelAmount returns how many of the empty spaces are filled with random barrels
+func (config GridConfig) BarrelAmount() int {
+	return int(math.Round(float64(config.GetEmptySpaces()) * config.FillPercentage))
+}
+
+// CountBarrels returns how many barrels are on the grid
+func (config GridConfig) CountBarrels(grid Grid) int {
+	var count = 0
+	for _, row := range grid {
+		for _, tile := range row {
+			if tile == config.BarrelBlock {
+				count++
+			}
+		}
+	}
+
+	return count
 }
 
 // ShrinkGridOrder returns an array of coordinates, which represent tiles that are changed in order to shrink the grid
@@ -0,0 +1,66 @@
+package modules
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "bomberman-test")
+	if err != nil {
+		panic(err)
+	}
+	ReplayDir = dir
+	Storage, err = NewFileStore(dir + "/data.json")
+	if err != nil {
+		panic(err)
+	}
+
+	// players return to the global chat when they leave a lobby
+	config := NewGameConfig()
+	config.GameId = "global"
+	global := NewGame(config)
+	GlobalGames.Add(&global)
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// newTestUser adds a user whose messages are dropped to the global clients
+func newTestUser(t *testing.T, name string) *User {
+	user := &User{
+		UserId:   UserId(fmt.Sprintf("%s-%s", t.Name(), name)),
+		Username: name,
+		Conn:     &Connection{closed: true},
+	}
+	GlobalClients.Add(user)
+	t.Cleanup(func() {
+		GlobalGames.RemovePlayer("global", user.UserId)
+		GlobalClients.Del(user.UserId)
+	})
+	return user
+}
+
+// newTestLobby creates a lobby with the config and the players in it
+func newTestLobby(t *testing.T, config GameConfig, players int) (*Game, []*User) {
+	game := NewGame(config)
+	GlobalGames.Add(&game)
+	t.Cleanup(func() { GlobalGames.Del(game.GameId) })
+
+	var users []*User
+	for i := 0; i < players; i++ {
+		user := newTestUser(t, fmt.Sprint("player", i))
+		JoinLobby(user, game.GameId)
+		users = append(users, user)
+	}
+	return &game, users
+}
+
+// newTestGame creates a game with the players and begins its first round, the test runs the game loop by calling Update
+func newTestGame(t *testing.T, players int) (*Game, []*User) {
+	game, users := newTestLobby(t, NewGameConfig(), players)
+	game.BeginRound()
+	return game, users
+}
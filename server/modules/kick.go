@@ -0,0 +1,165 @@
+package modules
+
+import "time"
+
+const (
+	bombSlideTime = 100 * time.Millisecond // How long a kicked bomb takes to slide over one tile
+	bombThrowTime = 500 * time.Millisecond // How long a thrown bomb is in the air
+	throwDistance = 3                      // How many tiles a bomb is thrown over
+)
+
+// directionSteps maps the move directions to grid steps
+var directionSteps = map[string]Position{
+	"up":    {X: 0, Y: -1},
+	"right": {X: 1, Y: 0},
+	"down":  {X: 0, Y: 1},
+	"left":  {X: -1, Y: 0},
+}
+
+// KickPlayerBomb kicks the bomb on the tile next to the player, called by the game loop for queued kick inputs
+func KickPlayerBomb(user *User, msg KickBombInput) {
+	game := GlobalGames.GetGame(GameId(user.GameId))
+	if user.Lives <= 0 || user.Powerups.Kick <= 0 {
+		return
+	}
+
+	dir := directionSteps[msg.Direction]
+	tile := game.UserTile(user)
+	bomb, ok := game.bombTiles[Position{X: tile.X + dir.X, Y: tile.Y + dir.Y}]
+	if !ok {
+		return
+	}
+	game.KickBomb(bomb, msg.Direction)
+}
+
+// ThrowPlayerBomb throws the bomb the player is standing on or the one next to them, called by the game loop for queued throw inputs
+func ThrowPlayerBomb(user *User, msg ThrowBombInput) {
+	game := GlobalGames.GetGame(GameId(user.GameId))
+	if user.Lives <= 0 || user.Powerups.Punch <= 0 {
+		return
+	}
+
+	dir := directionSteps[msg.Direction]
+	tile := game.UserTile(user)
+	bomb, ok := game.bombTiles[tile]
+	if !ok {
+		bomb, ok = game.bombTiles[Position{X: tile.X + dir.X, Y: tile.Y + dir.Y}]
+	}
+	if !ok {
+		return
+	}
+	game.ThrowBomb(user, bomb, msg.Direction)
+}
+
+// UserTile returns the tile the center of the users character is on
+func (game *Game) UserTile(user *User) Position {
+	characterCenter := game.Config.CharacterSize / 2
+	return Position(game.CurrentTileOnGrid(AbsolutePosition{X: user.Position.X + characterCenter, Y: user.Position.Y + characterCenter}))
+}
+
+// KickBomb sends a bomb sliding to the direction until it hits an obstacle
+func (game *Game) KickBomb(bomb *Bomb, direction string) {
+	// bombs that are already sliding or in the air can't be kicked
+	if bomb.Direction != (Position{}) || bomb.LandTick > game.Tick {
+		return
+	}
+	next := Position{X: bomb.Position.X + directionSteps[direction].X, Y: bomb.Position.Y + directionSteps[direction].Y}
+	if !game.BombCanEnter(next) {
+		return
+	}
+
+	bomb.Direction = directionSteps[direction]
+	bomb.nextSlideTick = game.Tick + game.Ticks(bombSlideTime)
+}
+
+// ThrowBomb lobs a bomb throwDistance tiles to the direction. If the tile is blocked the bomb bounces further until it finds a free tile.
+func (game *Game) ThrowBomb(user *User, bomb *Bomb, direction string) {
+	if bomb.LandTick > game.Tick {
+		return
+	}
+
+	pos, ok := game.ThrowTarget(bomb.Position, direction)
+	if !ok {
+		return
+	}
+
+	from := bomb.Position
+	bomb.Direction = Position{}
+	bomb.LandTick = game.Tick + game.Ticks(bombThrowTime)
+	game.SetBombTile(bomb, pos)
+
+	game.Emit(BombMovedEvent{
+		UserId: user.UserId,
+		Bomb:   *bomb,
+		From:   from,
+		Thrown: true,
+	})
+}
+
+// ThrowTarget returns the tile a bomb thrown from the tile to the direction lands on, false if there is no free tile on its way.
+// Bombs thrown over the edge of the grid come down on the opposite side.
+func (game *Game) ThrowTarget(from Position, direction string) (Position, bool) {
+	width, height := game.Config.GridConfig.Width, game.Config.GridConfig.Height
+	dir := directionSteps[direction]
+	pos := from
+
+	// after a whole lap every tile on the way has been tried
+	for i := 1; i <= throwDistance+width+height; i++ {
+		pos = Position{X: (pos.X + dir.X + width) % width, Y: (pos.Y + dir.Y + height) % height}
+		if i >= throwDistance && pos != from && game.Grid[pos.Y][pos.X] == game.Config.GridConfig.EmptyBlock && game.bombTiles[pos] == nil {
+			return pos, true
+		}
+	}
+
+	return from, false
+}
+
+// UpdateMovingBombs slides the kicked bombs one tile further and lands the thrown bombs
+func (game *Game) UpdateMovingBombs() {
+	for _, bomb := range game.bombs {
+		if bomb.LandTick == game.Tick {
+			// players the bomb landed on can walk off it
+			game.SetBombTile(bomb, bomb.Position)
+		}
+
+		if bomb.Direction == (Position{}) || bomb.nextSlideTick > game.Tick {
+			continue
+		}
+
+		next := Position{X: bomb.Position.X + bomb.Direction.X, Y: bomb.Position.Y + bomb.Direction.Y}
+		if !game.BombCanEnter(next) {
+			bomb.Direction = Position{}
+			continue
+		}
+		from := bomb.Position
+		game.SetBombTile(bomb, next)
+		bomb.nextSlideTick = game.Tick + game.Ticks(bombSlideTime)
+
+		game.Emit(BombMovedEvent{
+			UserId: bomb.UserId,
+			Bomb:   *bomb,
+			From:   from,
+		})
+	}
+}
+
+// BombCanEnter returns a boolean indicating whether a sliding bomb can move to the tile: it has to be empty, without bombs and players
+func (game *Game) BombCanEnter(pos Position) bool {
+	if pos.X < 0 || pos.Y < 0 || pos.X >= game.Config.GridConfig.Width || pos.Y >= game.Config.GridConfig.Height {
+		return false
+	}
+	if game.Grid[pos.Y][pos.X] != game.Config.GridConfig.EmptyBlock {
+		return false
+	}
+	if _, ok := game.bombTiles[pos]; ok {
+		return false
+	}
+
+	for _, player := range game.AlivePlayers() {
+		if containsPosition(game.GetUserCollidingTiles(AbsolutePosition(player.Position)), pos) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,111 @@
+package modules
+
+import "testing"
+
+// newKickArena returns an arena with both players out of the way of row 3 and column 3 and a bomb on the tile (3,3) that doesn't explode on its own
+func newKickArena(t *testing.T) (*Game, []*User, *Bomb) {
+	game, users := newTestArena(t, 2)
+	tileSize := game.Config.GridConfig.Tilesize
+	users[0].Position = Position{X: 1*tileSize + 4, Y: 7*tileSize + 4}
+	users[1].Position = Position{X: 5*tileSize + 4, Y: 7*tileSize + 4}
+
+	bomb := placeTestBomb(game, users[0], Position{X: 3, Y: 3}, NormalBomb)
+	bomb.DetonateTick = 0
+	return game, users, bomb
+}
+
+func TestKickBombStops(t *testing.T) {
+	tests := []struct {
+		name      string
+		direction string
+		setup     func(game *Game, users []*User)
+		stop      Position
+	}{
+		{"wall at the edge", "right", func(game *Game, users []*User) {}, Position{X: 13, Y: 3}},
+		{"wall above", "up", func(game *Game, users []*User) {}, Position{X: 3, Y: 1}},
+		{"barrel", "right", func(game *Game, users []*User) {
+			game.Grid[3][7] = game.Config.GridConfig.BarrelBlock
+		}, Position{X: 6, Y: 3}},
+		{"other bomb", "right", func(game *Game, users []*User) {
+			placeTestBomb(game, users[1], Position{X: 7, Y: 3}, NormalBomb).DetonateTick = 0
+		}, Position{X: 6, Y: 3}},
+		{"player", "right", func(game *Game, users []*User) {
+			tileSize := game.Config.GridConfig.Tilesize
+			users[1].Position = Position{X: 7*tileSize + 4, Y: 3*tileSize + 4}
+		}, Position{X: 6, Y: 3}},
+		{"blocked right away", "right", func(game *Game, users []*User) {
+			game.Grid[3][4] = game.Config.GridConfig.BarrelBlock
+		}, Position{X: 3, Y: 3}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			game, users, bomb := newKickArena(t)
+			test.setup(game, users)
+
+			game.KickBomb(bomb, test.direction)
+			for i := 0; i < 100 && bomb.Direction != (Position{}); i++ {
+				game.Tick++
+				game.UpdateMovingBombs()
+			}
+
+			if bomb.Direction != (Position{}) {
+				t.Fatalf("bomb is still sliding at %v", bomb.Position)
+			}
+			if bomb.Position != test.stop {
+				t.Errorf("bomb stopped at %v, want %v", bomb.Position, test.stop)
+			}
+			if game.bombTiles[test.stop] != bomb {
+				t.Errorf("bomb is not on the tile %v", test.stop)
+			}
+		})
+	}
+}
+
+func TestThrowBombLands(t *testing.T) {
+	tests := []struct {
+		name      string
+		from      Position
+		direction string
+		setup     func(game *Game)
+		land      Position
+		thrown    bool
+	}{
+		{"free tile", Position{X: 3, Y: 3}, "right", func(game *Game) {}, Position{X: 6, Y: 3}, true},
+		{"bounces over a barrel", Position{X: 3, Y: 3}, "right", func(game *Game) {
+			game.Grid[3][6] = game.Config.GridConfig.BarrelBlock
+		}, Position{X: 7, Y: 3}, true},
+		{"wraps past the right edge", Position{X: 11, Y: 3}, "right", func(game *Game) {}, Position{X: 1, Y: 3}, true},
+		{"wraps past the top edge", Position{X: 3, Y: 3}, "up", func(game *Game) {}, Position{X: 3, Y: 11}, true},
+		{"no free tile", Position{X: 3, Y: 3}, "right", func(game *Game) {
+			for x := 1; x < game.Config.GridConfig.Width-1; x++ {
+				if x != 3 {
+					game.Grid[3][x] = game.Config.GridConfig.BarrelBlock
+				}
+			}
+		}, Position{X: 3, Y: 3}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			game, users, bomb := newKickArena(t)
+			game.SetBombTile(bomb, test.from)
+			test.setup(game)
+
+			game.ThrowBomb(users[0], bomb, test.direction)
+
+			if bomb.Position != test.land {
+				t.Errorf("bomb landed on %v, want %v", bomb.Position, test.land)
+			}
+			if game.bombTiles[test.land] != bomb {
+				t.Errorf("bomb is not on the tile %v", test.land)
+			}
+			if thrown := bomb.LandTick > game.Tick; thrown != test.thrown {
+				t.Errorf("bomb in the air = %t, want %t", thrown, test.thrown)
+			}
+			if test.thrown && bomb.LandTick != game.Tick+game.Ticks(bombThrowTime) {
+				t.Errorf("bomb lands on tick %d, want %d", bomb.LandTick, game.Tick+game.Ticks(bombThrowTime))
+			}
+		})
+	}
+}
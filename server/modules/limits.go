@@ -0,0 +1,155 @@
+package modules
+
+import (
+	"bomberman_dom/server/logger"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// InputLimits contains the limits for player inputs, players who keep exceeding them are kicked
+type InputLimits struct {
+	MaxInputsPerTick int // Inputs a player can send on one tick, the rest are dropped
+	MaxBombsPerTick  int // Bombs a player can place on one tick
+	MovesPerSecond   int // Movement steps a player can take per second on each axis
+	MoveBurst        int // Movement steps a player can save up on each axis to make up for network jitter
+	KickViolations   int // Ticks with violations within ViolationWindow before the player is kicked, 0 disables kicking
+	ViolationWindow  int // Seconds violations are remembered for
+}
+
+// NewInputLimits returns InputLimits filled with default values
+func NewInputLimits() InputLimits {
+	return InputLimits{
+		MaxInputsPerTick: 8,
+		MaxBombsPerTick:  1,
+		MovesPerSecond:   30,
+		MoveBurst:        10,
+		KickViolations:   40,
+		ViolationWindow:  10,
+	}
+}
+
+// inputLimiter tracks the inputs of one player in the game loop
+type inputLimiter struct {
+	tick       int        // tick the counters below belong to
+	inputs     int        // inputs on the tick
+	bombs      int        // bombs placed on the tick
+	moveBudget [2]float64 // movement steps the player can take horizontally and vertically
+	violations []int      // ticks on which the player exceeded the limits
+	kicked     bool
+}
+
+// UpdateLimiters gives every player the movement budget of one tick
+func (game *Game) UpdateLimiters() {
+	limits := game.Config.InputLimits
+	perTick := float64(limits.MovesPerSecond) / float64(game.Config.TickRate)
+
+	for _, limiter := range game.limiters {
+		for axis, budget := range limiter.moveBudget {
+			limiter.moveBudget[axis] = math.Min(budget+perTick, float64(limits.MoveBurst))
+		}
+	}
+}
+
+// AllowInput checks the input against the players limits, inputs over the limits are dropped and logged as violations
+func (game *Game) AllowInput(user *User, input Input) bool {
+	limits := game.Config.InputLimits
+
+	limiter, ok := game.limiters[user.UserId]
+	if !ok {
+		burst := float64(limits.MoveBurst)
+		limiter = &inputLimiter{moveBudget: [2]float64{burst, burst}}
+		game.limiters[user.UserId] = limiter
+	}
+	if limiter.kicked {
+		return false
+	}
+	if limiter.tick != game.Tick {
+		limiter.tick = game.Tick
+		limiter.inputs = 0
+		limiter.bombs = 0
+	}
+
+	limiter.inputs++
+	if limiter.inputs > limits.MaxInputsPerTick {
+		game.Violation(user, limiter, fmt.Sprintf("sent more than %d inputs", limits.MaxInputsPerTick))
+		return false
+	}
+
+	switch msg := input.Message.(type) {
+	case MoveInput:
+		if msg.Direction == "stop" {
+			break
+		}
+		// moving diagonally takes a step on both axes
+		axis := 0
+		if msg.Direction == "up" || msg.Direction == "down" {
+			axis = 1
+		}
+		if limiter.moveBudget[axis] < 1 {
+			game.Violation(user, limiter, fmt.Sprintf("moved faster than %d steps per second", limits.MovesPerSecond))
+			return false
+		}
+		limiter.moveBudget[axis]--
+	case BombPlacedInput:
+		limiter.bombs++
+		if limiter.bombs > limits.MaxBombsPerTick {
+			game.Violation(user, limiter, fmt.Sprintf("placed more than %d bombs", limits.MaxBombsPerTick))
+			return false
+		}
+	}
+
+	return true
+}
+
+// Violation logs a player exceeding their limits and kicks them if they have done it too often.
+// Only the first violation of a tick is counted.
+func (game *Game) Violation(user *User, limiter *inputLimiter, reason string) {
+	if len(limiter.violations) != 0 && limiter.violations[len(limiter.violations)-1] == game.Tick {
+		return
+	}
+	logger.Warning(fmt.Sprintf("Cheat detection: %s (%s) in game %s %s on tick %d", user.Username, user.UserId, game.GameId, reason, game.Tick))
+
+	// forget the violations that are older than the window
+	windowStart := game.Tick - game.Ticks(time.Duration(game.Config.InputLimits.ViolationWindow)*time.Second)
+	for len(limiter.violations) != 0 && limiter.violations[0] <= windowStart {
+		limiter.violations = limiter.violations[1:]
+	}
+	limiter.violations = append(limiter.violations, game.Tick)
+
+	kickViolations := game.Config.InputLimits.KickViolations
+	if kickViolations > 0 && len(limiter.violations) >= kickViolations {
+		limiter.kicked = true
+		Kick(user, fmt.Sprintf("Kicked for exceeding the input limits %d times", len(limiter.violations)))
+	}
+}
+
+// Kick tells the user why they were kicked, ends their session and closes their connection.
+// The user is removed once their websocket reader notices the connection closing.
+func Kick(user *User, reason string) {
+	logger.Warning(fmt.Sprintf("Kicking %s (%s): %s", user.Username, user.UserId, reason))
+
+	err := user.Conn.Send(Kicked{Reason: reason})
+	HandleError(err)
+
+	GlobalSessions.Del(user.SessionToken)
+	user.Conn.Close(websocket.ClosePolicyViolation, "kicked")
+}
+
+// Close queues a close message with the code and reason after the messages waiting to be sent, the websocket is closed once they are written
+func (conn *Connection) Close(code int, reason string) {
+	conn.mut.Lock()
+	defer conn.mut.Unlock()
+	if conn.closed || conn.outbound == nil {
+		return
+	}
+
+	select {
+	case conn.outbound <- outboundMessage{frameType: websocket.CloseMessage, data: websocket.FormatCloseMessage(code, reason)}:
+	default:
+	}
+	conn.stopWriter()
+	conn.closed = true
+}
@@ -0,0 +1,97 @@
+package modules
+
+import "testing"
+
+func TestMoveBudget(t *testing.T) {
+	tests := []struct {
+		name    string
+		spend   int      // steps taken to the right before the budget refills
+		refill  int      // ticks the budget refills for
+		moves   []string // directions moved after the refill
+		allowed int
+	}{
+		{"burst", 0, 0, repeat("right", 12), 10},
+		{"burst cap", 0, 100, repeat("right", 12), 10},
+		{"refill", 10, 2, repeat("right", 5), 3},
+		{"other axis", 10, 0, repeat("down", 5), 5},
+		{"stopping is free", 10, 0, repeat("stop", 5), 5},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			game, users := newTestGame(t, 2)
+			// 1.5 steps per tick
+			game.Config.TickRate = 20
+			game.Config.InputLimits = InputLimits{MaxInputsPerTick: 100, MaxBombsPerTick: 1, MovesPerSecond: 30, MoveBurst: 10}
+
+			game.Tick = 1
+			for i := 0; i < test.spend; i++ {
+				game.AllowInput(users[0], Input{UserId: users[0].UserId, Message: MoveInput{Direction: "right"}})
+			}
+			for i := 0; i < test.refill; i++ {
+				game.Tick++
+				game.UpdateLimiters()
+			}
+
+			game.Tick++
+			allowed := 0
+			for _, direction := range test.moves {
+				if game.AllowInput(users[0], Input{UserId: users[0].UserId, Message: MoveInput{Direction: direction}}) {
+					allowed++
+				}
+			}
+			if allowed != test.allowed {
+				t.Errorf("%d moves allowed, want %d", allowed, test.allowed)
+			}
+		})
+	}
+}
+
+func TestViolationKick(t *testing.T) {
+	tests := []struct {
+		name           string
+		kickViolations int
+		ticks          []int // ticks the player exceeds the limits on
+		kicked         bool
+	}{
+		{"below the threshold", 3, []int{1, 2}, false},
+		{"threshold reached", 3, []int{1, 2, 3}, true},
+		{"one violation per tick", 3, []int{5, 5, 5}, false},
+		{"old violations forgotten", 3, []int{1, 300, 301}, false},
+		{"kicking disabled", 0, []int{1, 2, 3, 4, 5}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			game, users := newTestGame(t, 2)
+			// violations are remembered for 200 ticks
+			game.Config.TickRate = 20
+			game.Config.InputLimits.ViolationWindow = 10
+			game.Config.InputLimits.KickViolations = test.kickViolations
+
+			limiter := &inputLimiter{}
+			game.limiters[users[0].UserId] = limiter
+			for _, tick := range test.ticks {
+				game.Tick = tick
+				game.Violation(users[0], limiter, "exceeded the limits")
+			}
+
+			if limiter.kicked != test.kicked {
+				t.Fatalf("kicked = %t, want %t", limiter.kicked, test.kicked)
+			}
+			allowed := game.AllowInput(users[0], Input{UserId: users[0].UserId, Message: MoveInput{Direction: "stop"}})
+			if allowed == test.kicked {
+				t.Errorf("input of the player allowed = %t, kicked players can't send inputs", allowed)
+			}
+		})
+	}
+}
+
+// repeat returns a list with the direction count times
+func repeat(direction string, count int) []string {
+	directions := make([]string, count)
+	for i := range directions {
+		directions[i] = direction
+	}
+	return directions
+}
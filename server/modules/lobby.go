@@ -1,71 +1,59 @@
 package modules
 
-import (
-	"strings"
-)
-
 // CreateLobby creates new game
-func CreateLobby(data Data) {
+func CreateLobby(user *User) {
 	gameConfig := NewGameConfig()
 	game := NewGame(gameConfig)
+	game.HostId = user.UserId
 
-	user := GlobalClients.GetUser(UserId(data.UserId))
 	user.ReadyState = false
 
 	GlobalGames.Add(&game)
 
-	LeaveLobby(data)
-
-	data.GameId = string(game.GameId)
-	JoinLobby(data)
+	SwitchLobby(user, game.GameId)
 }
 
-// QuickPlay joins first lobby that has a free spot or creates a new lobby if all current lobbies are full
-func QuickPlay(data Data) {
-	games := GlobalGames.List()
+// SwitchLobby moves the user from the game they are in to the lobby, it locks the games it changes
+func SwitchLobby(user *User, gameId GameId) {
+	// the spectators of a game whose last player leaves are sent to the global chat
+	_, unlock := LockUser(user, gameId, "global")
+	defer unlock()
 
-	if len(games) == 1 {
-		CreateLobby(data)
+	if !LobbyExists(user, gameId) {
 		return
 	}
-
-	for _, game := range games {
-
-		game := GlobalGames.GetGame(GameId(game.GameId))
-
-		if game.GameId == "global" {
-			continue
-		}
-		if len(game.Players) < 4 && game.Status != InGame {
-			LeaveLobby(data)
-			data.GameId = string(game.GameId)
-			JoinLobby(data)
-			return
-		}
-	}
-	
-	CreateLobby(data)
+	LeaveLobby(user)
+	JoinLobby(user, gameId)
 }
 
 // JoinLobby adds player to game lobby and send out messages to other players
-func JoinLobby(data Data) {
-	game := GlobalGames.GetGame(GameId(data.GameId))
-	user := GlobalClients.GetUser(UserId(data.UserId))
+func JoinLobby(user *User, gameId GameId) {
+	game := GlobalGames.GetGame(gameId)
+	// joining a lobby cancels matchmaking
+	if gameId != "global" {
+		LeaveQueue(user)
+	}
+
+	// running games can only be watched, the break between the rounds of a series is part of the game
+	if gameId != "global" && (game.Status == InGame || game.Status == GameEnded) {
+		JoinAsSpectator(user, game)
+		return
+	}
 
 	// Check if game is already full
-	if (len(game.Players) >= 4 && game.GameId != "global") || game.Status == InGame {
-		err := user.Conn.Send(Data{
-			Type:    "lobbyError",
+	if len(game.Players) >= game.Config.MaxPlayers() && game.GameId != "global" {
+		err := user.Conn.Send(LobbyError{
 			Message: "Lobby is full or already in game!",
 		})
 		HandleError(err)
+		return
 	}
 
 	user.Time = CurrentTime()
-	user.GameId = string(game.GameId)
-	user.Color = RandColor(data.GameId)
-	user.Lives = game.Config.Lives
-	user.Powerups = NewPlayerPowerUps()
+	GlobalGames.SetUserGame(user, game.GameId)
+	user.Team = game.FreeTeam(user, user.Team)
+	user.Color = game.PlayerColor(user)
+	game.ResetPlayer(user)
 	GlobalGames.AddPlayer(game.GameId, user)
 
 	// name for systemMessage 'Message' field
@@ -74,8 +62,7 @@ func JoinLobby(data Data) {
 		chatName = "global"
 	}
 
-	err := user.Conn.Send(Data{
-		Type:     "joinChat",
+	err := user.Conn.Send(ChatJoined{
 		Username: user.Username,
 		Color:    user.Color,
 		Message:  "Joined " + chatName + " chat",
@@ -87,73 +74,86 @@ func JoinLobby(data Data) {
 		return
 	}
 
-	err = GlobalGames.BroadcastToOtherGamePlayers(game.GameId, user.UserId, Data{
-		Type:     "userJoinedLobby",
-		Username: user.Username,
-		UserId:   string(user.UserId),
-		GameId:   string(game.GameId),
-		Message:  user.Username + " joined the lobby",
-		Color:    user.Color,
-		Users:    GlobalGames.ListGamePlayers(game.GameId),
+	lobby := game.LobbyState()
+
+	err = GlobalGames.BroadcastToOtherGamePlayers(game.GameId, user.UserId, UserJoinedLobby{
+		LobbyState: lobby,
+		Username:   user.Username,
+		UserId:     user.UserId,
+		Message:    user.Username + " joined the lobby",
+		Color:      user.Color,
 	})
 	HandleError(err)
 
-	err = user.Conn.Send(Data{
-		Type:     "joinLobby",
-		Username: user.Username,
-		GameId:   string(game.GameId),
-		Message:  user.Username + " joined the lobby",
-		Color:    user.Color,
-		UserId:   string(user.UserId),
-		Users:    GlobalGames.ListGamePlayers(game.GameId),
+	err = user.Conn.Send(LobbyJoined{
+		LobbyState: lobby,
+		Username:   user.Username,
+		Message:    user.Username + " joined the lobby",
+		Color:      user.Color,
+		UserId:     user.UserId,
 	})
 	HandleError(err)
 }
 
+// ResetPlayer gives the user the lives and powerups a player starts the game with
+func (game *Game) ResetPlayer(user *User) {
+	user.Lives = game.Config.Lives
+	user.Invincibility = 0
+	user.Curse = Curse{}
+	user.CurseImmunity = 0
+	user.Collected = nil
+	user.Effects = nil
+	user.Powerups = NewPlayerPowerUps()
+	user.Spectator = false
+	user.Stats = MatchStats{}
+}
+
 // LeaveLobby removes player from game in GlobalGames and sends message to other players
-func LeaveLobby(data Data) {
-	user := GlobalClients.GetUser(UserId(data.UserId))
+func LeaveLobby(user *User) {
+	if LeaveSpectating(user) {
+		return
+	}
 
-	GlobalGames.RemovePlayer(GameId(user.GameId), user.UserId)
 	game := GlobalGames.GetGame(GameId(user.GameId))
-	user.ReadyState = false
-
-	msgType := "Lobby"
-	if game.Status == InGame {
-		msgType = "Game"
+	inGame := game.Status == InGame
+	// the player still takes part in the match of the running round
+	if inGame && GlobalGames.PlayerExists(game.GameId, user.UserId) {
+		game.leavers = append(game.leavers, *user)
 	}
 
+	GlobalGames.RemovePlayer(GameId(user.GameId), user.UserId)
+	user.ReadyState = false
+
 	if game.GameId == "global" {
 		return
 	}
 
 	if len(game.Players) == 0 {
+		game.DismissSpectators()
 		GlobalGames.Del(game.GameId)
 	} else {
-		err := GlobalGames.BroadcastToGame(GameId(user.GameId), Data{
-			Type:     "userLeft" + msgType,
-			UserId:   string(user.UserId),
+		game.PassHost()
+		err := GlobalGames.BroadcastToGame(GameId(user.GameId), UserLeft{
+			LobbyState: game.LobbyState(),
+			UserId:   user.UserId,
 			Message:  user.Username + " left the chat",
 			Username: user.Username,
-			GameId:   user.GameId,
 			Date:     CurrentTime(),
 			Color:    user.Color,
-			Users:    GlobalGames.ListGamePlayers(game.GameId),
+			InGame:   inGame,
 		})
 		HandleError(err)
 
-		ReadyToPlay(data)
-		if game.Status == InGame && len(game.AlivePlayers()) == 1 {
-			GameOver(game.GameId)
-		}
+		// when only one player is left in game, the game loop ends the game on the next tick
+		ReadyToPlay(user)
 	}
 
-	chat := Data{
-		Type:     "leave" + msgType,
+	chat := LobbyLeft{
 		Message:  user.Username + " left the chat",
 		Username: user.Username,
 		Date:     CurrentTime(),
 		Color:    user.Color,
+		InGame:   inGame,
 	}
 
 	err := user.Conn.Send(chat)
@@ -161,12 +161,11 @@ func LeaveLobby(data Data) {
 }
 
 // LobbyExists check if lobby exist in GlobalGames
-func LobbyExists(data Data, conn *Connection) bool {
-	gameId := GameId(strings.ToLower(data.GameId))
+func LobbyExists(user *User, gameId GameId) bool {
 	gameExists := GlobalGames.Exists(gameId)
 	if !gameExists {
-		message := Data{Type: "lobbyError", Message: "Lobby does not exist!"}
-		err := conn.Send(message)
+		message := LobbyError{Message: "Lobby does not exist!"}
+		err := user.Conn.Send(message)
 		HandleError(err)
 	}
 	return gameExists
@@ -0,0 +1,161 @@
+package modules
+
+import (
+	"sort"
+	"time"
+)
+
+// LockGames locks the games with the ids, games which don't exist are skipped. Returns the function which unlocks them again.
+// The lock of a game guards the game and the users in it, moving a user to another game needs the locks of both games.
+// Games are always locked in the order of their ids, so goroutines locking several games can't deadlock each other.
+func LockGames(gameIds ...GameId) func() {
+	ids := append([]GameId{}, gameIds...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var locked []*Game
+	for i, id := range ids {
+		if i > 0 && id == ids[i-1] {
+			continue
+		}
+		game := GlobalGames.GetGame(id)
+		if game == nil {
+			continue
+		}
+		game.mut.Lock()
+		locked = append(locked, game)
+	}
+
+	return func() {
+		for i := len(locked) - 1; i >= 0; i-- {
+			locked[i].mut.Unlock()
+		}
+	}
+}
+
+// LockUser locks the game the user is in together with the other games, the user can't move to another game until they are unlocked.
+// Returns the game of the user and the function which unlocks the games.
+func LockUser(user *User, others ...GameId) (*Game, func()) {
+	for {
+		gameId := GlobalGames.UserGame(user)
+		unlock := LockGames(append([]GameId{gameId}, others...)...)
+		// the user might have moved while the games were locked
+		if GlobalGames.UserGame(user) == gameId {
+			return GlobalGames.GetGame(gameId), unlock
+		}
+		unlock()
+	}
+}
+
+// QueueInput adds a player input to the input queue of the players game, it is applied on the next game tick
+func QueueInput(user *User, msg interface{}) {
+	game := GlobalGames.GetGame(GameId(user.GameId))
+	if game == nil || game.Status != InGame {
+		return
+	}
+
+	game.inputsMut.Lock()
+	defer game.inputsMut.Unlock()
+	game.inputs = append(game.inputs, Input{UserId: user.UserId, Message: msg})
+}
+
+// GameLoop runs the game simulation of the round with the configured tick rate until the round has ended.
+// The next round of a series starts its own loop.
+func (game *Game) GameLoop(round int) {
+	ticker := time.NewTicker(time.Second / time.Duration(game.Config.TickRate))
+	defer ticker.Stop()
+
+	for range ticker.C {
+		game.mut.Lock()
+		if !GlobalGames.Exists(game.GameId) || game.Status != InGame || game.Round != round {
+			game.mut.Unlock()
+			return
+		}
+		game.Update()
+		game.mut.Unlock()
+	}
+}
+
+// Update advances the game by one tick: applies queued inputs, powerup effects, curses, bombs, explosions, map shrinking and sudden death, then sends out the state changes and events of the tick
+func (game *Game) Update() {
+	game.Tick++
+
+	game.UpdateLimiters()
+	for _, input := range game.drainInputs() {
+		game.ApplyInput(input)
+	}
+	game.UpdateEffects()
+	game.UpdateCurses()
+	game.UpdateBombs()
+	game.UpdateExplosions()
+	game.UpdateShrink()
+	game.UpdateSuddenDeath()
+	game.UpdateScoreboard()
+
+	game.BroadcastState()
+
+	// the last players might all be eliminated on the same tick
+	if len(game.AliveTeams()) <= 1 || game.Tick >= game.Ticks(game.Config.Timing.MatchDuration) {
+		GameOver(game.GameId)
+	}
+}
+
+// ApplyInput applies a single queued player input to the game
+func (game *Game) ApplyInput(input Input) {
+	// the user might have disconnected or left the game after the input was queued
+	user := game.Player(input.UserId)
+	if user == nil {
+		user = game.Spectators[input.UserId]
+	}
+	if user == nil {
+		return
+	}
+	// spectators only receive the game state
+	if user.Spectator && input.Message != (resyncInput{}) {
+		return
+	}
+	if !game.AllowInput(user, input) {
+		return
+	}
+	game.replay.RecordInput(game.Tick, user.UserId, input.Message)
+
+	switch msg := input.Message.(type) {
+	case MoveInput:
+		MovePlayer(user, msg)
+	case BombPlacedInput:
+		BombPlaced(user)
+	case DetonateInput:
+		Detonate(user)
+	case KickBombInput:
+		KickPlayerBomb(user, msg)
+	case ThrowBombInput:
+		ThrowPlayerBomb(user, msg)
+	case resyncInput:
+		game.SendGameInfo(user)
+	}
+}
+
+// Emit adds data to the events that will be sent to all game players with the state of the current tick
+func (game *Game) Emit(msg Message) {
+	game.events = append(game.events, NewEnvelope(msg))
+}
+
+// Ticks converts a duration to the amount of game ticks, non zero durations last at least one tick
+func (game *Game) Ticks(duration time.Duration) int {
+	ticks := int(duration * time.Duration(game.Config.TickRate) / time.Second)
+	if ticks == 0 && duration > 0 {
+		return 1
+	}
+
+	return ticks
+}
+
+// drainInputs empties the input queue and returns the inputs that were in it
+func (game *Game) drainInputs() []Input {
+	game.inputsMut.Lock()
+	defer game.inputsMut.Unlock()
+
+	inputs := game.inputs
+	game.inputs = nil
+
+	return inputs
+}
@@ -0,0 +1,76 @@
+package modules
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUpdateEndsGame(t *testing.T) {
+	tests := []struct {
+		name       string
+		eliminated []int // players who lose all their lives on the same tick
+		over       bool
+	}{
+		{"everyone alive", nil, false},
+		{"one left standing", []int{0}, true},
+		{"double knockout", []int{0, 1}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			game, users := newTestGame(t, 2)
+			for _, i := range test.eliminated {
+				game.LoseLife(users[i], users[i].Lives, "")
+			}
+			game.Update()
+
+			if over := game.Status == GameEnded; over != test.over {
+				t.Fatalf("game over = %v, want %v", over, test.over)
+			}
+		})
+	}
+}
+
+func TestGameOverWithoutSurvivors(t *testing.T) {
+	game, users := newTestGame(t, 2)
+	for _, user := range users {
+		game.LoseLife(user, user.Lives, "")
+	}
+
+	if winners := game.GetWinner(); len(winners) != 0 {
+		t.Fatalf("winners = %v, want none", winners)
+	}
+	GameOver(game.GameId)
+	if game.Status != GameEnded {
+		t.Fatalf("status = %v, want GameEnded", game.Status)
+	}
+	if len(game.RoundWins) != 0 {
+		t.Fatalf("round wins = %v, a tie doesn't count for anyone", game.RoundWins)
+	}
+}
+
+// TestGameLoopWithHandlers runs the game loop while handlers change and read the game, like the websocket handlers do
+func TestGameLoopWithHandlers(t *testing.T) {
+	game, users := newTestLobby(t, NewGameConfig(), 2)
+	unlock := LockGames(game.GameId)
+	StartGame(users[0])
+	unlock()
+
+	for i := 0; i < 20; i++ {
+		unlock := LockGames(game.GameId)
+		QueueInput(users[0], MoveInput{Direction: "down"})
+		SendMessage(users[1], SendMessageInput{Message: "hello"})
+		unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	unlock = LockGames(game.GameId)
+	defer unlock()
+	if game.Tick == 0 {
+		t.Fatal("the game loop didn't run")
+	}
+	if users[0].Position.Y <= 50 {
+		t.Fatalf("player didn't move down: %v", users[0].Position)
+	}
+	game.Status = GameEnded
+}
@@ -0,0 +1,215 @@
+package modules
+
+import (
+	"bomberman_dom/server/logger"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	minMatchSize        = 2                // Fewest players a match can start with
+	fullMatchWait       = 30 * time.Second // After waiting this long, a match can start with fewer players than MatchSize
+	baseRatingRange     = 100.0            // Rating difference players are matched with right after joining the queue
+	ratingRangeGrowth   = 10.0             // How much the rating range grows per second of waiting
+	matchStartDelay     = 3 * time.Second  // Time players see the lobby of their match before it starts
+	matchmakingInterval = time.Second
+)
+
+// MatchSize is how many players are in a full QuickPlay match, it can be changed with SetMatchSize before matchmaking runs
+var MatchSize = defaultPlayers
+
+// SetMatchSize changes how many players are in a full QuickPlay match, the grid of the matches is sized for them
+func SetMatchSize(size int) error {
+	if size < minPlayers || size > maxPlayers {
+		return fmt.Errorf("match size has to be between %d and %d", minPlayers, maxPlayers)
+	}
+	MatchSize = size
+	return nil
+}
+
+// Matchmaking is the QuickPlay queue of players waiting for a match
+var Matchmaking = matchmakingQueue{Data: []*queuedPlayer{}, Mutex: &sync.Mutex{}}
+
+type matchmakingQueue struct {
+	Data []*queuedPlayer
+	*sync.Mutex
+}
+
+// queuedPlayer is a player waiting for a match
+type queuedPlayer struct {
+	userId UserId
+	rating float64
+	joined time.Time
+}
+
+// ratingRange returns the rating difference the player can be matched with, it grows the longer the player waits
+func (player *queuedPlayer) ratingRange(now time.Time) float64 {
+	return baseRatingRange + ratingRangeGrowth*now.Sub(player.joined).Seconds()
+}
+
+// Add adds a player to the queue, returns false if they are already queued
+func (mq *matchmakingQueue) Add(player *queuedPlayer) bool {
+	mq.Lock()
+	defer mq.Unlock()
+
+	for _, queued := range mq.Data {
+		if queued.userId == player.userId {
+			return false
+		}
+	}
+	mq.Data = append(mq.Data, player)
+
+	return true
+}
+
+// Remove removes a player from the queue, returns false if they weren't queued
+func (mq *matchmakingQueue) Remove(userId UserId) bool {
+	mq.Lock()
+	defer mq.Unlock()
+
+	for i, queued := range mq.Data {
+		if queued.userId == userId {
+			mq.Data = append(mq.Data[:i], mq.Data[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// FormMatches groups players of similar rating and removes them from the queue.
+// The longest waiting players are matched first, and everyone in a group has to be within each others rating range.
+func (mq *matchmakingQueue) FormMatches(now time.Time) (matches [][]UserId) {
+	mq.Lock()
+	defer mq.Unlock()
+
+	sort.SliceStable(mq.Data, func(i, j int) bool { return mq.Data[i].joined.Before(mq.Data[j].joined) })
+	matched := make(map[UserId]bool)
+
+	for _, player := range mq.Data {
+		if matched[player.userId] {
+			continue
+		}
+
+		var candidates []*queuedPlayer
+		for _, other := range mq.Data {
+			difference := math.Abs(player.rating - other.rating)
+			if other == player || matched[other.userId] || difference > player.ratingRange(now) || difference > other.ratingRange(now) {
+				continue
+			}
+			candidates = append(candidates, other)
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return math.Abs(player.rating-candidates[i].rating) < math.Abs(player.rating-candidates[j].rating)
+		})
+
+		group := []UserId{player.userId}
+		for _, candidate := range candidates {
+			if len(group) == MatchSize {
+				break
+			}
+			group = append(group, candidate.userId)
+		}
+
+		if len(group) == MatchSize || (len(group) >= minMatchSize && now.Sub(player.joined) >= fullMatchWait) {
+			for _, userId := range group {
+				matched[userId] = true
+			}
+			matches = append(matches, group)
+		}
+	}
+
+	var waiting []*queuedPlayer
+	for _, player := range mq.Data {
+		if !matched[player.userId] {
+			waiting = append(waiting, player)
+		}
+	}
+	mq.Data = waiting
+
+	return matches
+}
+
+// QuickPlay adds the user to the matchmaking queue, they are put in a match with players of similar rating
+func QuickPlay(user *User) {
+	if GlobalGames.UserGame(user) != "global" {
+		SwitchLobby(user, "global")
+	}
+	_, unlock := LockUser(user)
+	defer unlock()
+
+	rating := DefaultRating
+	if profile, err := Storage.Profile(user.ProfileId); err == nil {
+		rating = profile.Rating
+	}
+
+	if !Matchmaking.Add(&queuedPlayer{userId: user.UserId, rating: rating, joined: time.Now()}) {
+		return
+	}
+
+	err := user.Conn.Send(QueueJoined{Rating: rating})
+	HandleError(err)
+}
+
+// LeaveQueue removes the user from the matchmaking queue
+func LeaveQueue(user *User) {
+	if !Matchmaking.Remove(user.UserId) {
+		return
+	}
+
+	err := user.Conn.Send(QueueLeft{})
+	HandleError(err)
+}
+
+// RunMatchmaking forms matches from the queue until the server stops
+func RunMatchmaking() {
+	ticker := time.NewTicker(matchmakingInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		for _, match := range Matchmaking.FormMatches(now) {
+			go StartMatch(match)
+		}
+	}
+}
+
+// StartMatch creates a lobby for the matched players and starts the game after matchStartDelay
+func StartMatch(userIds []UserId) {
+	config := NewGameConfig()
+	config.GridConfig = NewGridConfig(MatchSize)
+	game := NewGame(config)
+
+	GlobalGames.Add(&game)
+	for _, userId := range userIds {
+		user := GlobalClients.GetUser(userId)
+		if user == nil {
+			continue
+		}
+		SwitchLobby(user, game.GameId)
+
+		_, unlock := LockUser(user)
+		if GameId(user.GameId) == game.GameId {
+			user.ReadyState = true
+			ReadyToPlay(user)
+		}
+		unlock()
+	}
+	logger.Log(fmt.Sprintf("Matched %d players in game '%s'", len(userIds), game.GameId))
+
+	time.Sleep(matchStartDelay)
+
+	unlock := LockGames(game.GameId)
+	defer unlock()
+	// players might have left the lobby while waiting
+	if !GlobalGames.Exists(game.GameId) || game.Status != InLobby {
+		return
+	}
+	players := GlobalGames.ListGamePlayers(game.GameId)
+	if len(players) < minMatchSize {
+		return
+	}
+	StartGame(GlobalClients.GetUser(players[0].UserId))
+}
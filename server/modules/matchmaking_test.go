@@ -0,0 +1,88 @@
+package modules
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFormMatches(t *testing.T) {
+	now := time.Now()
+	size := MatchSize
+	MatchSize = 2
+	t.Cleanup(func() { MatchSize = size })
+
+	player := func(userId UserId, rating float64, waited time.Duration) *queuedPlayer {
+		return &queuedPlayer{userId: userId, rating: rating, joined: now.Add(-waited)}
+	}
+
+	tests := []struct {
+		name    string
+		queue   []*queuedPlayer
+		want    [][]UserId
+		waiting int
+	}{
+		{
+			name:    "alone in the queue",
+			queue:   []*queuedPlayer{player("a", 1500, time.Minute)},
+			want:    nil,
+			waiting: 1,
+		},
+		{
+			name:    "similar ratings",
+			queue:   []*queuedPlayer{player("a", 1500, 0), player("b", 1550, 0)},
+			want:    [][]UserId{{"a", "b"}},
+			waiting: 0,
+		},
+		{
+			name:    "ratings too far apart",
+			queue:   []*queuedPlayer{player("a", 1500, 0), player("b", 1700, 0)},
+			want:    nil,
+			waiting: 2,
+		},
+		{
+			name:    "range grows while waiting",
+			queue:   []*queuedPlayer{player("a", 1500, 20*time.Second), player("b", 1700, 20*time.Second)},
+			want:    [][]UserId{{"a", "b"}},
+			waiting: 0,
+		},
+		{
+			name:    "longest waiting first, closest rating second",
+			queue:   []*queuedPlayer{player("a", 1560, 0), player("b", 1500, time.Second), player("c", 1520, 0)},
+			want:    [][]UserId{{"b", "c"}},
+			waiting: 1,
+		},
+		{
+			name: "several matches",
+			queue: []*queuedPlayer{
+				player("a", 1000, 0), player("b", 2000, 0), player("c", 1010, 0), player("d", 2010, 0),
+			},
+			want:    [][]UserId{{"a", "c"}, {"b", "d"}},
+			waiting: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			queue := matchmakingQueue{Data: test.queue, Mutex: &sync.Mutex{}}
+			if got := queue.FormMatches(now); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("matches = %v, want %v", got, test.want)
+			}
+			if len(queue.Data) != test.waiting {
+				t.Errorf("%d players waiting, want %d", len(queue.Data), test.waiting)
+			}
+		})
+	}
+
+	t.Run("smaller match after waiting", func(t *testing.T) {
+		MatchSize = 4
+		queue := matchmakingQueue{Data: []*queuedPlayer{player("a", 1500, 0), player("b", 1500, 0)}, Mutex: &sync.Mutex{}}
+		if got := queue.FormMatches(now); got != nil {
+			t.Errorf("matches = %v, want to wait for more players", got)
+		}
+		if got := queue.FormMatches(now.Add(fullMatchWait)); !reflect.DeepEqual(got, [][]UserId{{"a", "b"}}) {
+			t.Errorf("matches = %v, want a match of the two players", got)
+		}
+	})
+}
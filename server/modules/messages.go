@@ -0,0 +1,445 @@
+package modules
+
+/* ======================== CLIENT MESSAGES ======================== */
+
+// Hello is the first message a client sends, the envelope version is checked before anything else is accepted
+type Hello struct {
+	SessionToken string // resumes the session of a dropped connection
+}
+
+// AuthenticateInput sets the name and color of the user
+type AuthenticateInput struct {
+	Username     string `validate:"required"`
+	Color        string
+	AccountToken string // logs in to an existing profile
+}
+
+// SendMessageInput is a chat message sent by the user
+type SendMessageInput struct {
+	Username string
+	Color    string
+	Message  string `validate:"required"`
+	Team     bool   // Only the team of the user gets the message
+}
+
+// JoinLobbyInput joins the lobby with the given code
+type JoinLobbyInput struct {
+	GameId string `validate:"required"`
+}
+
+// CreateLobbyInput creates a new lobby and joins it
+type CreateLobbyInput struct{}
+
+// QuickPlayInput joins the matchmaking queue
+type QuickPlayInput struct{}
+
+// LeaveQueueInput leaves the matchmaking queue
+type LeaveQueueInput struct{}
+
+// ToggleReadyInput toggles the ready state of the user in the lobby
+type ToggleReadyInput struct{}
+
+// StartGameInput starts the game of the lobby
+type StartGameInput struct{}
+
+// UpdateLobbySettingsInput changes the settings of the lobby, only the lobby host can send it
+type UpdateLobbySettingsInput struct {
+	LobbySettings
+}
+
+// SwitchTeamInput moves the user to another team in a team mode lobby
+type SwitchTeamInput struct {
+	Team int `validate:"required,oneof=1 2"`
+}
+
+// LeaveLobbyInput leaves the current lobby or game and returns to the global chat
+type LeaveLobbyInput struct{}
+
+// MoveInput moves the player one step to the given direction
+type MoveInput struct {
+	Direction string `validate:"required,oneof=up right down left stop"`
+}
+
+func (MoveInput) MessageType() string { return "move" }
+
+// BombPlacedInput places a bomb on the tile the player is on
+type BombPlacedInput struct{}
+
+func (BombPlacedInput) MessageType() string { return "bombPlaced" }
+
+// DetonateInput explodes the oldest remote bomb of the player
+type DetonateInput struct{}
+
+func (DetonateInput) MessageType() string { return "detonate" }
+
+// KickBombInput kicks the bomb next to the player to the given direction
+type KickBombInput struct {
+	Direction string `validate:"required,oneof=up right down left"`
+}
+
+func (KickBombInput) MessageType() string { return "kickBomb" }
+
+// ThrowBombInput throws the bomb the player is standing on, or the one next to them, to the given direction
+type ThrowBombInput struct {
+	Direction string `validate:"required,oneof=up right down left"`
+}
+
+func (ThrowBombInput) MessageType() string { return "throwBomb" }
+
+// AckInput acknowledges the last game state received by the client
+type AckInput struct {
+	GameId string `validate:"required"`
+	Tick   int
+}
+
+// ProfileInput looks up a profile by its id or username, the users own profile is sent if both are empty
+type ProfileInput struct {
+	ProfileId string
+	Username  string
+}
+
+// ListReplaysInput asks for a page of the saved replays, the first page has the newest replays
+type ListReplaysInput struct {
+	Page int
+}
+
+// WatchReplayInput starts streaming a saved replay to the user
+type WatchReplayInput struct {
+	ReplayId string `validate:"required"`
+}
+
+/* ======================== SERVER MESSAGES ======================== */
+
+// Welcome accepts the handshake of the client
+type Welcome struct {
+	ProtocolVersion int
+	UserId          UserId
+	Resumed         bool // the session of the hello message was resumed
+}
+
+func (Welcome) MessageType() string { return "welcome" }
+
+// ProtocolError rejects a client which uses a different protocol version, the connection is closed after it
+type ProtocolError struct {
+	ProtocolVersion int
+	Message         string
+}
+
+func (ProtocolError) MessageType() string { return "protocolError" }
+
+// MessageError tells the client that a message it sent was rejected
+type MessageError struct {
+	Rejected string // type of the rejected message
+	Message  string
+}
+
+func (MessageError) MessageType() string { return "messageError" }
+
+// Kicked tells the user why they were kicked, the connection is closed after it
+type Kicked struct {
+	Reason string
+}
+
+func (Kicked) MessageType() string { return "kicked" }
+
+// UserCreated confirms the authentication of the user
+type UserCreated struct {
+	UserId       UserId
+	Username     string
+	Color        string
+	SessionToken string // sent in the hello message of a new connection to resume the session
+	ProfileId    string
+	AccountToken string // sent in the authenticate message to log in to the same profile again
+}
+
+func (UserCreated) MessageType() string { return "createUser" }
+
+// ChatJoined is a system message about joining a chat
+type ChatJoined struct {
+	Username string
+	Color    string
+	Message  string
+	Date     string
+}
+
+func (ChatJoined) MessageType() string { return "joinChat" }
+
+// ChatMessage is a chat message from a user
+type ChatMessage struct {
+	Username  string
+	Color     string
+	Message   string
+	Date      string
+	Spectator bool // The message was sent in the spectator chat
+	Team      bool // The message was sent in the team chat
+}
+
+func (ChatMessage) MessageType() string { return "message" }
+
+// Spectate tells the user that they are spectating the game, either after joining it or after being eliminated
+type Spectate struct {
+	GameId     GameId
+	Eliminated bool
+}
+
+func (Spectate) MessageType() string { return "spectate" }
+
+// LobbyError tells the user why joining a lobby failed
+type LobbyError struct {
+	Message string
+}
+
+func (LobbyError) MessageType() string { return "lobbyError" }
+
+// LobbyState contains the lobby code, its host and settings and everyone in it
+type LobbyState struct {
+	GameId   GameId
+	HostId   UserId
+	Settings LobbySettings
+	Users    []User
+}
+
+// LobbyJoined is sent to the user who joined a lobby
+type LobbyJoined struct {
+	LobbyState
+	UserId   UserId
+	Username string
+	Color    string
+	Message  string
+}
+
+func (LobbyJoined) MessageType() string { return "joinLobby" }
+
+// UserJoinedLobby is sent to the other users in the lobby when someone joins
+type UserJoinedLobby struct {
+	LobbyState
+	UserId   UserId
+	Username string
+	Color    string
+	Message  string
+}
+
+func (UserJoinedLobby) MessageType() string { return "userJoinedLobby" }
+
+// UserLeft is sent to the other users in the lobby or game when someone leaves
+type UserLeft struct {
+	LobbyState
+	UserId   UserId
+	Username string
+	Color    string
+	Message  string
+	Date     string
+	InGame   bool
+}
+
+func (msg UserLeft) MessageType() string {
+	if msg.InGame {
+		return "userLeftGame"
+	}
+	return "userLeftLobby"
+}
+
+// LobbyLeft is sent to the user who left a lobby or game
+type LobbyLeft struct {
+	Username string
+	Color    string
+	Message  string
+	Date     string
+	InGame   bool
+}
+
+func (msg LobbyLeft) MessageType() string {
+	if msg.InGame {
+		return "leaveGame"
+	}
+	return "leaveLobby"
+}
+
+// ReadyState tells the lobby that a user has changed their ready state
+type ReadyState struct {
+	GameId     GameId
+	UserId     UserId
+	Username   string
+	Color      string
+	ReadyState bool
+	Message    string
+}
+
+func (ReadyState) MessageType() string { return "userToggleReady" }
+
+// LobbySettingsChanged tells the lobby that the host has changed the settings, everyone's ready state is reset
+type LobbySettingsChanged struct {
+	LobbyState
+	Message string
+	Date    string
+}
+
+func (LobbySettingsChanged) MessageType() string { return "lobbySettings" }
+
+// TeamSwitched tells the lobby that a user has moved to another team
+type TeamSwitched struct {
+	LobbyState
+	UserId  UserId
+	Team    int
+	Message string
+	Date    string
+}
+
+func (TeamSwitched) MessageType() string { return "userSwitchTeam" }
+
+// GameStarted contains the initial game state and the end time of the game
+type GameStarted struct {
+	GameInfo Game
+	Date     string
+}
+
+func (GameStarted) MessageType() string { return "startGame" }
+
+func (StateDelta) MessageType() string { return "gameState" }
+
+// GameResult contains the result of the game and its winners
+type GameResult struct {
+	Result     string // "win" or "tie"
+	Winners    []User
+	Team       int // Winning team in team mode
+	Series     SeriesState
+	GameInfo   Game
+	Scoreboard []ScoreEntry
+}
+
+func (GameResult) MessageType() string { return "gameOver" }
+
+// ProfileInfo contains a profile with its lifetime stats and latest matches
+type ProfileInfo struct {
+	ProfileId string
+	Username  string
+	Color     string
+	Created   string
+	Rating    float64
+	Stats     LifetimeStats
+	Matches   []MatchRecord
+}
+
+func (ProfileInfo) MessageType() string { return "profile" }
+
+// QueueJoined tells the user they are waiting for a match
+type QueueJoined struct {
+	Rating float64
+}
+
+func (QueueJoined) MessageType() string { return "queueJoined" }
+
+// QueueLeft tells the user they are no longer waiting for a match
+type QueueLeft struct{}
+
+func (QueueLeft) MessageType() string { return "queueLeft" }
+
+// Replays lists a page of the saved replays
+type Replays struct {
+	Replays []ReplayInfo
+	Page    int
+	Pages   int
+}
+
+func (Replays) MessageType() string { return "replays" }
+
+/* ======================== GAME EVENTS ======================== */
+
+// MoveEvent is a player movement
+type MoveEvent struct {
+	UserId    UserId
+	Direction string
+	Position  Position
+}
+
+func (MoveEvent) MessageType() string { return "move" }
+
+// BombPlacedEvent is a bomb placed by a player
+type BombPlacedEvent struct {
+	UserId UserId
+	Bomb   Bomb
+}
+
+func (BombPlacedEvent) MessageType() string { return "bombPlaced" }
+
+// BombExplodedEvent is an exploded bomb with its explosion area
+type BombExplodedEvent struct {
+	UserId UserId
+	Bomb   Bomb
+}
+
+func (BombExplodedEvent) MessageType() string { return "bombExploded" }
+
+// BombMovedEvent is a bomb sliding one tile after a kick or thrown by a player
+type BombMovedEvent struct {
+	UserId UserId // Player who threw the bomb, the bomb owner for sliding bombs
+	Bomb   Bomb
+	From   Position
+	Thrown bool
+}
+
+func (BombMovedEvent) MessageType() string { return "bombMoved" }
+
+// LoseLifeEvent is a player losing lives
+type LoseLifeEvent struct {
+	UserId   UserId
+	Lives    int
+	KillerId UserId // Owner of the bomb, empty if the player was killed by the shrinking map
+}
+
+func (LoseLifeEvent) MessageType() string { return "loseLife" }
+
+// Scoreboard contains the stats of every player in the game, it is sent whenever they change
+type Scoreboard struct {
+	Players []ScoreEntry
+}
+
+func (Scoreboard) MessageType() string { return "scoreboard" }
+
+// CurseEvent is a player getting or losing a skull curse
+type CurseEvent struct {
+	UserId     UserId
+	Curse      CurseType // Empty when the curse has ended
+	ExpireTick int
+	FromUserId UserId // Cursed player who passed the curse on by touching, empty for curses from skull powerups
+}
+
+func (CurseEvent) MessageType() string { return "curse" }
+
+// PowerupsDroppedEvent tells that an eliminated player has scattered their powerups onto the tiles
+type PowerupsDroppedEvent struct {
+	UserId UserId
+	Tiles  []Position
+}
+
+func (PowerupsDroppedEvent) MessageType() string { return "powerupsDropped" }
+
+// PowerupsDestroyedEvent tells that an explosion has destroyed the powerups on the tiles
+type PowerupsDestroyedEvent struct {
+	Tiles []Position
+}
+
+func (PowerupsDestroyedEvent) MessageType() string { return "powerupsDestroyed" }
+
+// SuddenDeathEvent tells that sudden death has started
+type SuddenDeathEvent struct {
+	OneLife  bool
+	MaxFlame bool
+	Message  string
+	Date     string
+}
+
+func (SuddenDeathEvent) MessageType() string { return "suddenDeath" }
+
+// ShrinkMapEvent tells that tiles have been changed to walls by the shrinking map
+type ShrinkMapEvent struct{}
+
+func (ShrinkMapEvent) MessageType() string { return "shrinkMap" }
+
+// UpdateGridEvent tells that an explosion is over and the grid can be redrawn
+type UpdateGridEvent struct {
+	UserId UserId
+	Bomb   Bomb
+}
+
+func (UpdateGridEvent) MessageType() string { return "updateGrid" }
@@ -17,40 +17,42 @@ type AbsolutePosition Position
 type GridPosition Position
 
 
-// MovePlayer Checks if possible to move player 
-func MovePlayer(data Data) {
-	user := GlobalClients.GetUser(UserId(data.UserId))
+// MovePlayer Checks if possible to move player, called by the game loop for queued move inputs
+func MovePlayer(user *User, msg MoveInput) {
 	game := GlobalGames.GetGame(GameId(user.GameId))
 
 	if user.Lives <= 0 {
 		return
 	}
 
-	if !game.Move(user, data.Message, user.Powerups.Speed) {
-		distance, err := game.DistanceToTileEdge(AbsolutePosition(user.Position), data.Message)
+	direction, speed := msg.Direction, user.Powerups.Speed
+	switch user.Curse.Type {
+	case ReversedControls:
+		direction = reversedDirections[direction]
+	case MinimumSpeed:
+		speed = cursedSpeed
+	}
+
+	if !game.Move(user, direction, speed) {
+		distance, err := game.DistanceToTileEdge(AbsolutePosition(user.Position), direction)
 		if err != nil {
 			logger.Error(err)
 			return
 		}
-		if distance < user.Powerups.Speed && distance < game.Config.GridConfig.Tilesize && distance != 0 {
-			game.Move(user, data.Message, distance)
+		if distance < speed && distance < game.Config.GridConfig.Tilesize && distance != 0 {
+			game.Move(user, direction, distance)
 		}
 	}
 
-	data = Data{
-		Type:     "move",
-		Message:  data.Message,
-		UserId:   string(user.UserId),
-		GameInfo: game.PrepareForSend(),
-		Position: user.Position,
-	}
-
-	// send new coordinates to all game players
-	err := GlobalGames.BroadcastToGame(game.GameId, data)
-	HandleError(err)
+	// send new coordinates to all game players at the end of the tick
+	game.Emit(MoveEvent{
+		Direction: direction,
+		UserId:    user.UserId,
+		Position:  user.Position,
+	})
 }
 
-// GetUserCollidingTiles Check 3x3 neighbouring tiles, return x,y coordinates 
+// GetUserCollidingTiles Check 3x3 neighbouring tiles, return x,y coordinates. Bombs on the tiles are checked with BombBlocks.
 func (game *Game) GetUserCollidingTiles(userPosition AbsolutePosition) (collidingTiles []Position) {
 	var userX, userY = userPosition.X, userPosition.Y
 	var userSize, tileSize = game.Config.CharacterSize, game.Config.GridConfig.Tilesize
@@ -120,16 +122,24 @@ func (game *Game) Move(user *User, direction string, distance int) bool {
 
 	var emptyBlock = game.Config.GridConfig.EmptyBlock
 	var collidingTiles = game.GetUserCollidingTiles(AbsolutePosition(newPosition))
-	// Check if new user position is valid (not in any non-empty blocks)
+	// Check if new user position is valid (not in any non-empty blocks or bombs)
 	for _, pos := range collidingTiles {
 		// check if colliding tile is not empty
 		var tile = game.Grid[pos.Y][pos.X]
 		if tile != emptyBlock {
 			return false
 		}
+		if game.BombBlocks(user, pos) {
+			// players with the kick powerup send the bomb they walk into sliding
+			if user.Powerups.Kick > 0 {
+				game.KickBomb(game.bombTiles[pos], direction)
+			}
+			return false
+		}
 	}
 	// Change users position to new position
 	user.Position = Position(newPosition)
+	game.LeaveBombs(user, collidingTiles)
 
 	for _, pos := range collidingTiles {
 		// Check if new user position overlaps with any active powerups on the grid
@@ -140,8 +150,8 @@ func (game *Game) Move(user *User, direction string, distance int) bool {
 			game.ActivePowerUps[pos.Y][pos.X] = emptyBlock
 		}
 		// check if walked into expolsion
-		if game.ActiveExplosions[pos.Y][pos.X] == Explosion {
-			game.LoseLife(user, 1)
+		if game.ActiveExplosions[pos.Y][pos.X] == Explosion && game.CanHurt(game.ExplosionOwner(pos), user) {
+			game.LoseLife(user, 1, game.ExplosionOwner(pos))
 		}
 	}
 	
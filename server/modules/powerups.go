@@ -1,32 +1,64 @@
 package modules
 
 import (
-	"math"
 	"math/rand"
 	"time"
 )
 
-// Powerup represents powerups in the game
+// Powerup represents powerups in the game, they are defined in the powerups file
 type Powerup struct {
-	Name   PowerupName
-	Amount int
-	Icon   int
+	Name     PowerupName
+	Amount   int // How many barrels contain the powerup
+	Icon     int
+	Weight   float64 // Drop weight of the powerup compared to the others
+	Minimum  int     // How many barrels contain the powerup at least with weighted drops
+	Stat     string  // Field of PlayerPowerUps the powerup changes, "Curse" curses the player
+	Step     int     // How much one pickup adds to an integer stat
+	Value    string  // Value one pickup sets a text stat to
+	Max      int     // Maximum of the player stat, 0 for no limit
+	Duration float64 // Seconds the effect lasts, 0 for the rest of the game
 }
 
 type PowerupName string
 
 // PlayerPowerUps represents number of powerups player has
 type PlayerPowerUps struct {
-	Bombs int
-	Flame int
-	Speed int
+	Bombs    int
+	Flame    int
+	Speed    int
+	Kick     int      // Walking into a bomb sends it sliding
+	Punch    int      // Bombs can be thrown over tiles
+	BombType BombType // Type of the bombs the player places, picking up another bomb type replaces it
+	Maxed    MaxedPowerups
 }
 
-// GetRandomPowerups returns an array of integers which represent powerups with the integer that is in their Powerup.Icon field
-func (config GameConfig) GetRandomPowerups() []PowerupName {
-	// get barrel amount
-	var nonWallAmount = config.GridConfig.GetEmptySpaces()
-	var barrelAmount = int(math.Round(float64(nonWallAmount) * config.GridConfig.FillPercentage))
+// MaxedPowerups tells which player stats have reached the Max of their powerup
+type MaxedPowerups struct {
+	Bombs bool
+	Flame bool
+	Speed bool
+	Kick  bool
+	Punch bool
+}
+
+// Add returns the stat increased by the Step of the powerup, capped at its Max
+func (powerup Powerup) Add(stat int) int {
+	stat += powerup.Step
+	if powerup.Max != 0 && stat > powerup.Max {
+		stat = powerup.Max
+	}
+	return stat
+}
+
+// Maxed returns a boolean indicating whether the stat has reached the Max of the powerup
+func (powerup Powerup) Maxed(stat int) bool {
+	return powerup.Max != 0 && stat >= powerup.Max
+}
+
+// GetRandomPowerups returns an array of integers which represent powerups with the integer that is in their Powerup.Icon field, one for each barrel on the grid
+func (config GameConfig) GetRandomPowerups(grid Grid) []PowerupName {
+	// get barrel amount, including the barrels placed next to the corner areas
+	var barrelAmount = config.GridConfig.CountBarrels(grid)
 	var emptyBarrel = PowerupName("Nothing")
 
 	var powerups []PowerupName
@@ -63,14 +95,101 @@ func NewPlayerPowerUps() PlayerPowerUps {
 	}
 }
 
-// AddPowerUp Adds powerUps to player 
+// AddPowerUp Adds the powerup shown with the icon to the player
 func (user *User) AddPowerUp(powerupIcon int, game *Game) {
-	switch powerupIcon {
-	case game.Config.Powerups["Speed"].Icon:
-		user.Powerups.Speed += 2
-	case game.Config.Powerups["Flame"].Icon:
-		user.Powerups.Flame += 1
-	case game.Config.Powerups["Bomb"].Icon:
-		user.Powerups.Bombs += 1
+	powerup, ok := game.Config.PowerupByIcon(powerupIcon)
+	if !ok {
+		return
+	}
+
+	game.ApplyPowerup(user, powerup)
+	user.Stats.PowerupsCollected++
+	user.Collected = append(user.Collected, powerup.Name)
+}
+
+// PlacedBombs returns how many of the users bombs are on the grid
+func (game *Game) PlacedBombs(user *User) (placed int) {
+	for _, bomb := range game.bombs {
+		if bomb.UserId == user.UserId {
+			placed++
+		}
+	}
+	return placed
+}
+
+// DropPowerups scatters the powerups an eliminated player has collected onto random free tiles
+func (game *Game) DropPowerups(user *User) {
+	if !game.Config.DropPowerups || len(user.Collected) == 0 {
+		return
+	}
+
+	var dropped []Position
+	for i, pos := range game.FreeTiles() {
+		if i >= len(user.Collected) {
+			break
+		}
+		game.ActivePowerUps[pos.Y][pos.X] = game.Config.Powerups[user.Collected[i]].Icon
+		dropped = append(dropped, pos)
+	}
+	user.Collected = nil
+
+	game.Emit(PowerupsDroppedEvent{
+		UserId: user.UserId,
+		Tiles:  dropped,
+	})
+}
+
+// DestroyPowerups removes the powerups lying on empty tiles in the explosion area, powerups revealed by the explosion from barrels stay
+func (game *Game) DestroyPowerups(bomb Bomb) {
+	if !game.Config.DestroyPowerups {
+		return
+	}
+	var empty = game.Config.GridConfig.EmptyBlock
+
+	var destroyed []Position
+	for _, pos := range append([]Position{bomb.Position}, flatten(bomb.ExplosionArea)...) {
+		if game.Grid[pos.Y][pos.X] == empty && game.ActivePowerUps[pos.Y][pos.X] != empty {
+			game.ActivePowerUps[pos.Y][pos.X] = empty
+			destroyed = append(destroyed, pos)
+		}
+	}
+
+	if len(destroyed) != 0 {
+		game.Emit(PowerupsDestroyedEvent{Tiles: destroyed})
+	}
+}
+
+// FreeTiles returns the empty tiles without powerups, bombs, explosions and players in a random order
+func (game *Game) FreeTiles() (tiles []Position) {
+	var empty = game.Config.GridConfig.EmptyBlock
+	players := game.AlivePlayers()
+
+	for y, row := range game.Grid {
+		for x, tile := range row {
+			pos := Position{X: x, Y: y}
+			if tile != empty || game.ActivePowerUps[y][x] != empty || game.ActiveExplosions[y][x] != Nothing || game.bombTiles[pos] != nil {
+				continue
+			}
+			touched := false
+			for _, player := range players {
+				touched = touched || containsPosition(game.GetUserCollidingTiles(AbsolutePosition(player.Position)), pos)
+			}
+			if !touched {
+				tiles = append(tiles, pos)
+			}
+		}
+	}
+
+	rand.Shuffle(len(tiles), func(i, j int) {
+		tiles[i], tiles[j] = tiles[j], tiles[i]
+	})
+	return tiles
+}
+
+// flatten returns the positions of all directions of an explosion area
+func flatten(area [][]Position) (positions []Position) {
+	for _, direction := range area {
+		positions = append(positions, direction...)
 	}
+	return positions
 }
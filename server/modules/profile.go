@@ -0,0 +1,232 @@
+package modules
+
+import (
+	"bomberman_dom/server/logger"
+
+	"github.com/google/uuid"
+)
+
+// matchHistoryLength is how many matches are sent with a profile
+const matchHistoryLength = 20
+
+// Profile is a persistent player account, the client keeps its token to log back in
+type Profile struct {
+	ProfileId string
+	Token     string
+	Username  string
+	Color     string
+	Created   string
+	LastSeen  string
+	Rating    float64
+	Stats     LifetimeStats
+}
+
+// LifetimeStats are the totals of every match a profile has played
+type LifetimeStats struct {
+	Games             int
+	Wins              int
+	Ties              int
+	Kills             int
+	Deaths            int
+	SelfDestructs     int
+	BombsPlaced       int
+	BarrelsBroken     int
+	PowerupsCollected int
+}
+
+// MatchStats are counted for each player during a game
+type MatchStats struct {
+	Kills             int // Lives taken from other players
+	Deaths            int // Lives lost, including self destructs
+	SelfDestructs     int // Lives lost to the players own bombs
+	BombsPlaced       int
+	BarrelsBroken     int
+	PowerupsCollected int
+	EliminatedTick    int // 0 if the player survived
+}
+
+// MatchRecord is the result of a finished match
+type MatchRecord struct {
+	MatchId string
+	GameId  GameId
+	Date    string
+	Result  string // "win" or "tie"
+	Players []MatchPlayer
+}
+
+// MatchPlayer is the result of one player in a finished match
+type MatchPlayer struct {
+	ProfileId    string
+	Username     string
+	Color        string
+	Winner       bool
+	Left         bool // Left the game before they were eliminated
+	Place        int
+	Lives        int
+	Rating       float64 // Rating after the match
+	RatingChange float64
+	MatchStats
+}
+
+// LoadProfile finds the profile of the account token or creates a new one, the profile gets the username and color the user logged in with
+func LoadProfile(token string, username string, color string) Profile {
+	login := func(profile *Profile) {
+		profile.Username = username
+		profile.Color = color
+		profile.LastSeen = CurrentTime()
+	}
+
+	if token != "" {
+		found, err := Storage.ProfileByToken(token)
+		if err == nil {
+			var profile Profile
+			profile, err = Storage.Update(found.ProfileId, login)
+			if err == nil {
+				return profile
+			}
+		}
+		if err != ErrNotFound {
+			logger.Error(err)
+		}
+	}
+
+	profile := Profile{
+		ProfileId: uuid.NewString(),
+		Token:     uuid.NewString(),
+		Created:   CurrentTime(),
+		Rating:    DefaultRating,
+	}
+	login(&profile)
+	err := Storage.SaveProfile(profile)
+	HandleError(err)
+
+	return profile
+}
+
+// RecordMatch saves the result of a finished game and adds it to the lifetime stats of its players.
+// Players who left the game before they were eliminated are placed last.
+func RecordMatch(game *Game, result GameResult, leavers []User) {
+	match := MatchRecord{
+		MatchId: uuid.NewString(),
+		GameId:  game.GameId,
+		Date:    CurrentTime(),
+		Result:  result.Result,
+	}
+
+	winners := make(map[UserId]bool)
+	for _, winner := range result.Winners {
+		winners[winner.UserId] = true
+	}
+
+	players := make([]User, 0, len(result.GameInfo.Players)+len(leavers))
+	for _, player := range result.GameInfo.Players {
+		players = append(players, *player)
+	}
+	players = append(players, leavers...)
+
+	left := make(map[UserId]bool)
+	for _, leaver := range leavers {
+		left[leaver.UserId] = leaver.Stats.EliminatedTick == 0
+	}
+
+	for _, player := range players {
+		if player.ProfileId == "" {
+			continue
+		}
+		match.Players = append(match.Players, MatchPlayer{
+			ProfileId:  player.ProfileId,
+			Username:   player.Username,
+			Color:      player.Color,
+			Winner:     winners[player.UserId],
+			Left:       left[player.UserId],
+			Lives:      player.Lives,
+			MatchStats: player.Stats,
+		})
+	}
+
+	ratings := make([]float64, len(match.Players))
+	for i, player := range match.Players {
+		ratings[i] = DefaultRating
+		profile, err := Storage.Profile(player.ProfileId)
+		if err != nil {
+			logger.Error(err)
+			continue
+		}
+		ratings[i] = profile.Rating
+	}
+
+	places := MatchPlaces(match.Players)
+	changes := RatingChanges(ratings, places)
+	for i := range match.Players {
+		match.Players[i].Place = places[i]
+		match.Players[i].RatingChange = changes[i]
+		match.Players[i].Rating = ratings[i] + changes[i]
+	}
+
+	err := Storage.AddMatch(match)
+	HandleError(err)
+
+	for _, player := range match.Players {
+		player := player
+		// other matches of the player might have finished since the ratings were read, so only the change is added
+		_, err = Storage.Update(player.ProfileId, func(profile *Profile) {
+			profile.Rating += player.RatingChange
+			profile.Stats.Games++
+			if player.Winner && match.Result == "win" {
+				profile.Stats.Wins++
+			}
+			if player.Winner && match.Result == "tie" {
+				profile.Stats.Ties++
+			}
+			profile.Stats.Kills += player.Kills
+			profile.Stats.Deaths += player.Deaths
+			profile.Stats.SelfDestructs += player.SelfDestructs
+			profile.Stats.BombsPlaced += player.BombsPlaced
+			profile.Stats.BarrelsBroken += player.BarrelsBroken
+			profile.Stats.PowerupsCollected += player.PowerupsCollected
+		})
+		if err != ErrNotFound {
+			HandleError(err)
+		}
+	}
+}
+
+// LookupProfile sends the user a profile with its lifetime stats and latest matches, their own profile if no id or username is given
+func LookupProfile(user *User, msg ProfileInput) {
+	var profile Profile
+	var err error
+
+	switch {
+	case msg.ProfileId != "":
+		profile, err = Storage.Profile(msg.ProfileId)
+	case msg.Username != "":
+		profile, err = Storage.ProfileByUsername(msg.Username)
+	default:
+		profile, err = Storage.Profile(user.ProfileId)
+	}
+	if err != nil {
+		if err != ErrNotFound {
+			logger.Error(err)
+		}
+		err = user.Conn.Send(MessageError{
+			Rejected: "profile",
+			Message:  "Profile does not exist",
+		})
+		HandleError(err)
+		return
+	}
+
+	matches, err := Storage.Matches(profile.ProfileId, matchHistoryLength)
+	HandleError(err)
+
+	err = user.Conn.Send(ProfileInfo{
+		ProfileId: profile.ProfileId,
+		Username:  profile.Username,
+		Color:     profile.Color,
+		Created:   profile.Created,
+		Rating:    profile.Rating,
+		Stats:     profile.Stats,
+		Matches:   matches,
+	})
+	HandleError(err)
+}
@@ -0,0 +1,59 @@
+package modules
+
+import "testing"
+
+func TestRecordMatchRatesLeavers(t *testing.T) {
+	game, users := newTestGame(t, 3)
+	for _, user := range users {
+		user.ProfileId = user.Username + "-" + t.Name()
+		err := Storage.SaveProfile(Profile{ProfileId: user.ProfileId, Token: user.ProfileId, Rating: DefaultRating})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	game.Tick = 100
+	game.LoseLife(users[1], users[1].Lives, "")
+	LeaveLobby(users[2])
+	if GlobalGames.PlayerExists(game.GameId, users[2].UserId) {
+		t.Fatal("the leaver is still a player of the game")
+	}
+
+	RecordMatch(game, GameResult{
+		Result:   "win",
+		Winners:  []User{*users[0]},
+		GameInfo: game.PrepareForSend(),
+	}, game.leavers)
+
+	wantPlaces := []int{1, 2, 3}
+	for i, user := range users {
+		matches, err := Storage.Matches(user.ProfileId, 1)
+		if err != nil || len(matches) != 1 {
+			t.Fatalf("matches of %s = %v, %v", user.Username, matches, err)
+		}
+
+		var player *MatchPlayer
+		for j := range matches[0].Players {
+			if matches[0].Players[j].ProfileId == user.ProfileId {
+				player = &matches[0].Players[j]
+			}
+		}
+		if player == nil {
+			t.Fatalf("%s isn't in the match", user.Username)
+		}
+		if player.Place != wantPlaces[i] {
+			t.Errorf("%s placed %d, want %d", user.Username, player.Place, wantPlaces[i])
+		}
+		if player.Left != (i == 2) {
+			t.Errorf("%s left = %v", user.Username, player.Left)
+		}
+	}
+
+	profile, err := Storage.Profile(users[2].ProfileId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Rating >= DefaultRating || profile.Stats.Games != 1 {
+		t.Errorf("leaver has rating %v after %d games, want a loss", profile.Rating, profile.Stats.Games)
+	}
+}
@@ -0,0 +1,313 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gorilla/websocket"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// ProtocolVersion is the version of the websocket protocol, clients have to send it in every envelope
+const ProtocolVersion = 2
+
+const (
+	sendQueueSize = 256              // Messages a connection can have waiting to be written, clients which fall further behind are disconnected
+	writeTimeout  = 10 * time.Second // Time a single write to a websocket can take
+)
+
+// Codec encodes the messages sent to a connection
+type Codec interface {
+	Encode(v interface{}) ([]byte, error)
+	FrameType() int
+}
+
+// Subprotocols lists the websocket subprotocols in the order the server prefers them, clients which don't request any get JSON
+var Subprotocols = []string{"bomberman.msgpack", "bomberman.cbor", "bomberman.json"}
+
+var codecs = map[string]Codec{
+	"bomberman.msgpack": MsgpackCodec{},
+	"bomberman.cbor":    CBORCodec{},
+	"bomberman.json":    JSONCodec{},
+}
+
+// CodecFor returns the codec of the negotiated websocket subprotocol, JSON is used by default
+func CodecFor(subprotocol string) Codec {
+	codec, ok := codecs[subprotocol]
+	if !ok {
+		return JSONCodec{}
+	}
+
+	return codec
+}
+
+// JSONCodec encodes messages as JSON text frames
+type JSONCodec struct{}
+
+func (JSONCodec) Encode(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) FrameType() int {
+	return websocket.TextMessage
+}
+
+// MsgpackCodec encodes messages as MessagePack binary frames, structs use the same field names as the JSON encoding
+type MsgpackCodec struct{}
+
+func (MsgpackCodec) Encode(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := msgpack.NewEncoder(&buf)
+	encoder.SetCustomStructTag("json")
+	encoder.UseCompactInts(true)
+	encoder.UseCompactFloats(true)
+	err := encoder.Encode(v)
+
+	return buf.Bytes(), err
+}
+
+func (MsgpackCodec) FrameType() int {
+	return websocket.BinaryMessage
+}
+
+// cborEncoding encodes floats in the shortest form which keeps their value, structs are encoded with their JSON field names
+var cborEncoding = func() cbor.EncMode {
+	mode, err := cbor.EncOptions{ShortestFloat: cbor.ShortestFloat16}.EncMode()
+	if err != nil {
+		panic(err)
+	}
+	return mode
+}()
+
+// CBORCodec encodes messages as CBOR binary frames, structs use the same field names as the JSON encoding
+type CBORCodec struct{}
+
+func (CBORCodec) Encode(v interface{}) ([]byte, error) {
+	return cborEncoding.Marshal(v)
+}
+
+func (CBORCodec) FrameType() int {
+	return websocket.BinaryMessage
+}
+
+// Message is a payload that can be sent to clients
+type Message interface {
+	MessageType() string
+}
+
+// Envelope wraps every message sent to clients
+type Envelope struct {
+	Version int
+	Type    string
+	Payload Message
+}
+
+// RawEnvelope is an envelope received from a client, its payload is decoded once the type is known
+type RawEnvelope struct {
+	Version int
+	Type    string
+	Payload json.RawMessage
+}
+
+// UnmarshalJSON decodes an envelope saved by the server, the payload is kept as raw JSON because its type is not known
+func (envelope *Envelope) UnmarshalJSON(data []byte) error {
+	var raw RawEnvelope
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+
+	envelope.Version = raw.Version
+	envelope.Type = raw.Type
+	envelope.Payload = rawMessage{messageType: raw.Type, payload: raw.Payload}
+
+	return nil
+}
+
+// rawMessage is a decoded message payload which is sent on as it is
+type rawMessage struct {
+	messageType string
+	payload     json.RawMessage
+}
+
+func (msg rawMessage) MessageType() string { return msg.messageType }
+
+func (msg rawMessage) MarshalJSON() ([]byte, error) {
+	if len(msg.payload) == 0 {
+		return []byte("null"), nil
+	}
+	return msg.payload, nil
+}
+
+func (msg rawMessage) EncodeMsgpack(encoder *msgpack.Encoder) error {
+	value, err := msg.value()
+	if err != nil {
+		return err
+	}
+	return encoder.Encode(value)
+}
+
+func (msg rawMessage) MarshalCBOR() ([]byte, error) {
+	value, err := msg.value()
+	if err != nil {
+		return nil, err
+	}
+	return cborEncoding.Marshal(value)
+}
+
+// value decodes the JSON payload, so it can be encoded with the binary codecs
+func (msg rawMessage) value() (interface{}, error) {
+	var value interface{}
+	if len(msg.payload) == 0 {
+		return value, nil
+	}
+	err := json.Unmarshal(msg.payload, &value)
+	return value, err
+}
+
+// Input is a message received from a player which is applied by the game loop
+type Input struct {
+	UserId  UserId
+	Message interface{}
+}
+
+// NewEnvelope wraps a message into an envelope of the current protocol version
+func NewEnvelope(msg Message) Envelope {
+	return Envelope{
+		Version: ProtocolVersion,
+		Type:    msg.MessageType(),
+		Payload: msg,
+	}
+}
+
+// Send Sends a message to certain connection, encoded with the codec negotiated for the connection
+func (conn *Connection) Send(msg Message) error {
+	conn.mut.Lock()
+	codec := conn.Codec
+	conn.mut.Unlock()
+	if codec == nil {
+		codec = JSONCodec{}
+	}
+
+	data, err := codec.Encode(NewEnvelope(msg))
+	if err != nil {
+		return err
+	}
+
+	conn.mut.Lock()
+	defer conn.mut.Unlock()
+	// messages to a disconnected user are dropped, they get a full resync when they resume the session
+	if conn.closed || conn.outbound == nil {
+		return nil
+	}
+
+	select {
+	case conn.outbound <- outboundMessage{frameType: codec.FrameType(), data: data}:
+		return nil
+	default:
+		// the client doesn't keep up with its messages, it can resume the session on a new connection
+		conn.stopWriter()
+		conn.Conn.Close()
+		return fmt.Errorf("send queue of the connection is full, closing the websocket")
+	}
+}
+
+// NewConnection returns a connection to the websocket, messages sent to it are written by a writer of its own
+func NewConnection(wsconn *websocket.Conn, codec Codec) *Connection {
+	conn := &Connection{Conn: wsconn, Codec: codec}
+	conn.startWriter()
+	return conn
+}
+
+// startWriter starts the writer of the websocket of the connection, the connection's mut has to be held or the connection not shared yet
+func (conn *Connection) startWriter() {
+	conn.outbound = make(chan outboundMessage, sendQueueSize)
+	go writeMessages(conn.Conn, conn.outbound)
+}
+
+// stopWriter closes the send queue of the connection, its writer writes the queued messages and closes the websocket.
+// The connection's mut has to be held.
+func (conn *Connection) stopWriter() {
+	if conn.outbound != nil {
+		close(conn.outbound)
+		conn.outbound = nil
+	}
+}
+
+// writeMessages writes the messages of the queue to the websocket until the queue is closed, then closes the websocket.
+// Every write has to finish within writeTimeout, after a failed write the rest of the queue is dropped.
+func writeMessages(wsconn *websocket.Conn, outbound chan outboundMessage) {
+	defer wsconn.Close()
+
+	failed := false
+	for msg := range outbound {
+		if failed {
+			continue
+		}
+
+		deadline := time.Now().Add(writeTimeout)
+		var err error
+		if msg.frameType == websocket.CloseMessage {
+			err = wsconn.WriteControl(websocket.CloseMessage, msg.data, deadline)
+		} else {
+			err = wsconn.SetWriteDeadline(deadline)
+			if err == nil {
+				err = wsconn.WriteMessage(msg.frameType, msg.data)
+			}
+		}
+		if err != nil {
+			failed = true
+			wsconn.Close()
+		}
+	}
+}
+
+// Validate checks the fields of a message against their `validate` tags.
+// Supported rules are "required", which rejects zero values, and "oneof=a b c", which only allows the listed values.
+func Validate(msg interface{}) error {
+	value := reflect.Indirect(reflect.ValueOf(msg))
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		tag, ok := field.Tag.Lookup("validate")
+		if !ok {
+			continue
+		}
+
+		for _, rule := range strings.Split(tag, ",") {
+			switch {
+			case rule == "required":
+				if value.Field(i).IsZero() {
+					return fmt.Errorf("field '%s' is required", field.Name)
+				}
+			case strings.HasPrefix(rule, "oneof="):
+				allowed := strings.Fields(strings.TrimPrefix(rule, "oneof="))
+				fieldValue := fmt.Sprint(value.Field(i).Interface())
+				if !contains(allowed, fieldValue) {
+					return fmt.Errorf("field '%s' has to be one of %v, got '%s'", field.Name, allowed, fieldValue)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// contains returns a boolean indicating whether the value is in the list
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
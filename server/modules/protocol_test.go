@@ -0,0 +1,155 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// decoders decode the messages of the binary codecs
+var decoders = map[string]func(data []byte) (interface{}, error){
+	"bomberman.msgpack": func(data []byte) (interface{}, error) {
+		var decoded interface{}
+		err := msgpack.Unmarshal(data, &decoded)
+		return decoded, err
+	},
+	"bomberman.cbor": func(data []byte) (interface{}, error) {
+		mode, err := cbor.DecOptions{DefaultMapType: reflect.TypeOf(map[string]interface{}{})}.DecMode()
+		if err != nil {
+			return nil, err
+		}
+		var decoded interface{}
+		err = mode.Unmarshal(data, &decoded)
+		return decoded, err
+	},
+}
+
+// normalize converts a decoded value to the types encoding/json decodes into, so values decoded from different formats can be compared
+func normalize(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			out[key] = normalize(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(value))
+		for i, item := range value {
+			out[i] = normalize(item)
+		}
+		return out
+	case []byte:
+		return base64.StdEncoding.EncodeToString(value)
+	}
+
+	number := reflect.ValueOf(v)
+	switch number.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(number.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(number.Uint())
+	case reflect.Float32, reflect.Float64:
+		return number.Float()
+	}
+	return v
+}
+
+func TestBinaryCodecsUseJSONFieldNames(t *testing.T) {
+	messages := map[string]interface{}{
+		"state": NewEnvelope(StateDelta{
+			Tick:    12,
+			Tiles:   []TileChange{{X: 1, Y: 2, Value: 1}},
+			Players: []PlayerDelta{{UserId: "a", Position: &Position{X: 50, Y: 94}}},
+			Events:  []Envelope{NewEnvelope(MoveEvent{UserId: "a", Direction: "down", Position: Position{X: 50, Y: 94}})},
+		}),
+		"chat":           NewEnvelope(ChatMessage{Message: "hello", Username: "alice", Date: CurrentTime(), Color: "#D72C41"}),
+		"replayed event": Envelope{Version: ProtocolVersion, Type: "move", Payload: rawMessage{messageType: "move", payload: []byte(`{"UserId":"a","Direction":"up"}`)}},
+	}
+
+	for name, message := range messages {
+		jsonData, err := json.Marshal(message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var want interface{}
+		decoder := json.NewDecoder(bytes.NewReader(jsonData))
+		if err := decoder.Decode(&want); err != nil {
+			t.Fatal(err)
+		}
+
+		for subprotocol, decode := range decoders {
+			t.Run(name+" "+subprotocol, func(t *testing.T) {
+				data, err := CodecFor(subprotocol).Encode(message)
+				if err != nil {
+					t.Fatal(err)
+				}
+				decoded, err := decode(data)
+				if err != nil {
+					t.Fatalf("can't decode the message: %v", err)
+				}
+				if got := normalize(decoded); !reflect.DeepEqual(got, want) {
+					t.Errorf("decoded message differs from its JSON encoding:\ngot  %.500v\nwant %.500v", got, want)
+				}
+			})
+		}
+	}
+}
+
+// payloadSamples are the messages sent most often during a game: a tick where a player moves and a tick where the grid shrinks
+var payloadSamples = map[string]Envelope{
+	"move": NewEnvelope(StateDelta{
+		Tick:     120,
+		BaseTick: 119,
+		Tiles:    []TileChange{},
+		Players:  []PlayerDelta{{UserId: "0f8fad5b-d9cb-469f-a165-70867728950e", Position: &Position{X: 94, Y: 50}}},
+		Events:   []Envelope{NewEnvelope(MoveEvent{UserId: "0f8fad5b-d9cb-469f-a165-70867728950e", Direction: "right", Position: Position{X: 94, Y: 50}})},
+	}),
+	"shrinkMap": NewEnvelope(StateDelta{
+		Tick:     1800,
+		BaseTick: 1799,
+		Tiles:    []TileChange{{X: 5, Y: 1, Value: 1}, {X: 6, Y: 1, Value: 1}},
+		Players:  []PlayerDelta{},
+		Events:   []Envelope{NewEnvelope(ShrinkMapEvent{})},
+	}),
+}
+
+func TestBinaryPayloadsAreSmaller(t *testing.T) {
+	for name, message := range payloadSamples {
+		jsonData, err := JSONCodec{}.Encode(message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, subprotocol := range []string{"bomberman.msgpack", "bomberman.cbor"} {
+			data, err := CodecFor(subprotocol).Encode(message)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(data) >= len(jsonData) {
+				t.Errorf("%s payload is %d bytes with %s, %d bytes with JSON", name, len(data), subprotocol, len(jsonData))
+			}
+		}
+	}
+}
+
+// BenchmarkEncode compares the encoding speed and payload size of the codecs, the size is reported as bytes/msg
+func BenchmarkEncode(b *testing.B) {
+	for name, message := range payloadSamples {
+		for _, subprotocol := range Subprotocols {
+			codec := CodecFor(subprotocol)
+			b.Run(name+"/"+strings.TrimPrefix(subprotocol, "bomberman."), func(b *testing.B) {
+				var data []byte
+				for i := 0; i < b.N; i++ {
+					data, _ = codec.Encode(message)
+				}
+				b.ReportMetric(float64(len(data)), "bytes/msg")
+			})
+		}
+	}
+}
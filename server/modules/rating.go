@@ -0,0 +1,73 @@
+package modules
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// DefaultRating is the rating of new profiles
+	DefaultRating = 1500.0
+	// ratingK is the most a rating can change in one match
+	ratingK = 32.0
+)
+
+// RatingChanges returns the rating change of each player of a match, a lower place is better and equal places are draws.
+// Every pair of players is rated as a separate Elo game, the changes are scaled so a match is worth at most ratingK.
+func RatingChanges(ratings []float64, places []int) []float64 {
+	changes := make([]float64, len(ratings))
+	if len(ratings) < 2 {
+		return changes
+	}
+
+	for i := range ratings {
+		for j := range ratings {
+			if i == j {
+				continue
+			}
+
+			expected := 1 / (1 + math.Pow(10, (ratings[j]-ratings[i])/400))
+			actual := 0.5
+			if places[i] < places[j] {
+				actual = 1
+			} else if places[i] > places[j] {
+				actual = 0
+			}
+			changes[i] += ratingK / float64(len(ratings)-1) * (actual - expected)
+		}
+	}
+
+	return changes
+}
+
+// MatchPlaces returns the place of each player, winners share the first place, the rest are placed by how long they survived and players who left are last
+func MatchPlaces(players []MatchPlayer) []int {
+	order := make([]int, len(players))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return survived(players[order[a]]) > survived(players[order[b]])
+	})
+
+	places := make([]int, len(players))
+	for rank, index := range order {
+		places[index] = rank + 1
+		if rank > 0 && survived(players[order[rank-1]]) == survived(players[index]) {
+			places[index] = places[order[rank-1]]
+		}
+	}
+
+	return places
+}
+
+// survived returns how long the player survived, winners survived until the end and players who left didn't survive at all
+func survived(player MatchPlayer) int {
+	if player.Left {
+		return -1
+	}
+	if player.Winner || player.EliminatedTick == 0 {
+		return math.MaxInt
+	}
+	return player.EliminatedTick
+}
@@ -0,0 +1,107 @@
+package modules
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestMatchPlaces(t *testing.T) {
+	tests := []struct {
+		name    string
+		players []MatchPlayer
+		want    []int
+	}{
+		{
+			name: "winner and eliminations",
+			players: []MatchPlayer{
+				{MatchStats: MatchStats{EliminatedTick: 100}},
+				{Winner: true},
+				{MatchStats: MatchStats{EliminatedTick: 300}},
+			},
+			want: []int{3, 1, 2},
+		},
+		{
+			name: "eliminated on the same tick share the place",
+			players: []MatchPlayer{
+				{Winner: true},
+				{MatchStats: MatchStats{EliminatedTick: 200}},
+				{MatchStats: MatchStats{EliminatedTick: 200}},
+				{MatchStats: MatchStats{EliminatedTick: 50}},
+			},
+			want: []int{1, 2, 2, 4},
+		},
+		{
+			name: "survivors of a tie share the first place",
+			players: []MatchPlayer{
+				{},
+				{},
+				{MatchStats: MatchStats{EliminatedTick: 10}},
+			},
+			want: []int{1, 1, 3},
+		},
+		{
+			name: "players who left are last",
+			players: []MatchPlayer{
+				{Left: true},
+				{MatchStats: MatchStats{EliminatedTick: 1}},
+				{Winner: true},
+				{Left: true},
+			},
+			want: []int{3, 2, 1, 3},
+		},
+		{
+			name:    "no players",
+			players: nil,
+			want:    []int{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := MatchPlaces(test.players); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("places = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRatingChanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		ratings []float64
+		places  []int
+		want    []float64
+	}{
+		{"single player", []float64{1500}, []int{1}, []float64{0}},
+		{"equal ratings", []float64{1500, 1500}, []int{1, 2}, []float64{16, -16}},
+		{"draw between equal ratings", []float64{1500, 1500}, []int{1, 1}, []float64{0, 0}},
+		{"favourite wins", []float64{1900, 1500}, []int{1, 2}, []float64{2.91, -2.91}},
+		{"underdog wins", []float64{1500, 1900}, []int{1, 2}, []float64{29.09, -29.09}},
+		{"three players", []float64{1500, 1500, 1500}, []int{1, 2, 3}, []float64{16, 0, -16}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := RatingChanges(test.ratings, test.places)
+			if len(got) != len(test.want) {
+				t.Fatalf("changes = %v, want %v", got, test.want)
+			}
+
+			var total float64
+			for i := range got {
+				total += got[i]
+				if math.Abs(got[i]-test.want[i]) > 0.01 {
+					t.Errorf("changes = %v, want %v", got, test.want)
+					break
+				}
+				if math.Abs(got[i]) > ratingK {
+					t.Errorf("change %v is more than %v", got[i], ratingK)
+				}
+			}
+			if math.Abs(total) > 1e-9 {
+				t.Errorf("changes add up to %v, rating points are only exchanged between players", total)
+			}
+		})
+	}
+}
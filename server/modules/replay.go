@@ -0,0 +1,384 @@
+package modules
+
+import (
+	"bomberman_dom/server/logger"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReplayDir is the directory the replays of finished games are saved to
+var ReplayDir = "replays"
+
+// replayIdPattern matches valid replay ids, so they can't be used to read files outside of ReplayDir
+var replayIdPattern = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+// ReplayPageSize is how many replays are listed on one page
+var ReplayPageSize = 20
+
+// replayIndex holds the info of the saved replays, newest first. It's read from the info files in ReplayDir on the first request and kept up to date by Save.
+var replayIndex struct {
+	sync.Mutex
+	loaded  bool
+	replays []ReplayInfo
+}
+
+// Replay is a recording of a game, inputs and state changes are stored with the tick they happened on
+type Replay struct {
+	ReplayId       string
+	GameId         GameId
+	Date           string
+	Config         GameConfig
+	Grid           Grid
+	BarrelContents []PowerupName
+	Drops          []RecordedDrop // Powerups rolled for the barrels as they were broken, in every drop mode
+	Players        []User         // Players and their spawn positions
+	Inputs         []RecordedInput
+	Ticks          []StateDelta // Changes of every tick that changed anything, compared to the previous recorded tick
+	Result         *GameResult
+
+	last *StateSnapshot
+}
+
+// RecordedInput is a player input accepted by the game loop
+type RecordedInput struct {
+	Tick   int
+	UserId UserId
+	Input  Envelope
+}
+
+// RecordedDrop is the powerup a broken barrel dropped
+type RecordedDrop struct {
+	Tick     int
+	Position Position
+	Powerup  PowerupName
+}
+
+// ReplayInfo describes a saved replay, it's saved next to the replay so the replays can be listed without reading them
+type ReplayInfo struct {
+	ReplayId string
+	GameId   GameId
+	Date     string
+	Players  []string
+}
+
+// NewReplay starts recording the game from its current state, called when the game starts
+func (game *Game) NewReplay() *Replay {
+	replay := Replay{
+		ReplayId:       string(game.GameId) + "-" + time.Now().Format("20060102-150405"),
+		GameId:         game.GameId,
+		Date:           CurrentTime(),
+		Config:         game.Config,
+		Grid:           game.PrepareForSend().Grid,
+		BarrelContents: append([]PowerupName{}, game.BarrelContents...),
+		Players:        GlobalGames.ListGamePlayers(game.GameId),
+		last:           game.Snapshot(),
+	}
+
+	return &replay
+}
+
+// RecordInput adds an accepted input to the replay, inputs which aren't client messages are skipped
+func (replay *Replay) RecordInput(tick int, userId UserId, input interface{}) {
+	msg, ok := input.(Message)
+	if !ok {
+		return
+	}
+
+	replay.Inputs = append(replay.Inputs, RecordedInput{
+		Tick:   tick,
+		UserId: userId,
+		Input:  NewEnvelope(msg),
+	})
+}
+
+// RecordDrop adds the powerup of a broken barrel to the replay
+func (replay *Replay) RecordDrop(tick int, pos Position, name PowerupName) {
+	replay.Drops = append(replay.Drops, RecordedDrop{
+		Tick:     tick,
+		Position: pos,
+		Powerup:  name,
+	})
+}
+
+// RecordTick adds the changes of the tick to the replay, ticks without changes or events are skipped
+func (replay *Replay) RecordTick(snapshot *StateSnapshot) {
+	delta := snapshot.Delta(replay.last)
+	replay.last = snapshot
+
+	if len(delta.Tiles) == 0 && len(delta.Players) == 0 && len(delta.Events) == 0 {
+		return
+	}
+	replay.Ticks = append(replay.Ticks, delta)
+}
+
+// Save writes the replay to ReplayDir
+func (replay *Replay) Save() {
+	err := os.MkdirAll(ReplayDir, 0755)
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+
+	data, err := json.Marshal(replay)
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+
+	err = os.WriteFile(filepath.Join(ReplayDir, replay.ReplayId+".json"), data, 0644)
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+
+	info := replay.Info()
+	data, err = json.Marshal(info)
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+	err = os.WriteFile(filepath.Join(ReplayDir, replay.ReplayId+".info.json"), data, 0644)
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+
+	replayIndex.Lock()
+	if replayIndex.loaded {
+		replayIndex.replays = append(replayIndex.replays, info)
+		sortReplays(replayIndex.replays)
+	}
+	replayIndex.Unlock()
+	logger.Log("Saved replay " + replay.ReplayId)
+}
+
+// Info returns the description of the replay, which is listed to the users
+func (replay *Replay) Info() ReplayInfo {
+	info := ReplayInfo{
+		ReplayId: replay.ReplayId,
+		GameId:   replay.GameId,
+		Date:     replay.Date,
+	}
+	for _, player := range replay.Players {
+		info.Players = append(info.Players, player.Username)
+	}
+	return info
+}
+
+// LoadReplay reads a saved replay from ReplayDir
+func LoadReplay(replayId string) (*Replay, error) {
+	if !replayIdPattern.MatchString(replayId) {
+		return nil, errors.New("invalid replay id")
+	}
+
+	data, err := os.ReadFile(filepath.Join(ReplayDir, replayId+".json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var replay Replay
+	err = json.Unmarshal(data, &replay)
+
+	return &replay, err
+}
+
+// ListReplays sends the user a page of the saved replays, newest first
+func ListReplays(user *User, msg ListReplaysInput) {
+	err := user.Conn.Send(ReplayPage(msg.Page))
+	HandleError(err)
+}
+
+// ReplayPage returns a page of the saved replays, pages out of range return the first or the last page
+func ReplayPage(page int) Replays {
+	replayIndex.Lock()
+	defer replayIndex.Unlock()
+	if !replayIndex.loaded {
+		replayIndex.replays = LoadReplayInfos()
+		replayIndex.loaded = true
+	}
+	total := len(replayIndex.replays)
+	pages := (total + ReplayPageSize - 1) / ReplayPageSize
+
+	if page >= pages {
+		page = pages - 1
+	}
+	if page < 0 {
+		page = 0
+	}
+	start := page * ReplayPageSize
+	end := start + ReplayPageSize
+	if end > total {
+		end = total
+	}
+
+	return Replays{
+		Replays: append([]ReplayInfo{}, replayIndex.replays[start:end]...),
+		Page:    page,
+		Pages:   pages,
+	}
+}
+
+// LoadReplayInfos reads the info files of the replays in ReplayDir, newest first.
+// Replays saved without an info file are read once and get one.
+func LoadReplayInfos() []ReplayInfo {
+	files, err := os.ReadDir(ReplayDir)
+	if err != nil && !os.IsNotExist(err) {
+		logger.Error(err)
+	}
+
+	names := make(map[string]bool)
+	for _, file := range files {
+		names[file.Name()] = true
+	}
+
+	replays := []ReplayInfo{}
+	for name := range names {
+		if strings.HasSuffix(name, ".info.json") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		replayId := strings.TrimSuffix(name, ".json")
+
+		if names[replayId+".info.json"] {
+			data, err := os.ReadFile(filepath.Join(ReplayDir, replayId+".info.json"))
+			var info ReplayInfo
+			if err == nil {
+				err = json.Unmarshal(data, &info)
+			}
+			if err == nil {
+				replays = append(replays, info)
+				continue
+			}
+			logger.Warning("Can't read the info of replay '" + replayId + "': " + err.Error())
+		}
+
+		replay, err := LoadReplay(replayId)
+		if err != nil {
+			continue
+		}
+		info := replay.Info()
+		data, err := json.Marshal(info)
+		if err == nil {
+			err = os.WriteFile(filepath.Join(ReplayDir, replayId+".info.json"), data, 0644)
+		}
+		HandleError(err)
+		replays = append(replays, info)
+	}
+	sortReplays(replays)
+
+	return replays
+}
+
+// sortReplays sorts the replays newest first
+func sortReplays(replays []ReplayInfo) {
+	sort.Slice(replays, func(i, j int) bool { return replays[i].Date > replays[j].Date })
+}
+
+// WatchReplay streams a saved replay to the user with the tick rate of the recorded game.
+// The replay is sent with the same messages as a running game, so the client shows it like a spectator.
+func WatchReplay(user *User, msg WatchReplayInput) {
+	if user.GameId != "global" {
+		err := user.Conn.Send(LobbyError{Message: "Leave the lobby to watch a replay!"})
+		HandleError(err)
+		return
+	}
+
+	replay, err := LoadReplay(msg.ReplayId)
+	if err != nil {
+		logger.Warning("Can't load replay '" + msg.ReplayId + "': " + err.Error())
+		err = user.Conn.Send(LobbyError{Message: "Replay does not exist!"})
+		HandleError(err)
+		return
+	}
+
+	go replay.Stream(user)
+}
+
+// Stream sends the replay to the user, it stops early if the user disconnects or joins a lobby
+func (replay *Replay) Stream(user *User) {
+	game := Game{
+		GameId:  replay.GameId,
+		Status:  InGame,
+		Players: make(map[UserId]*User),
+		Grid:    replay.Grid,
+		Config:  replay.Config,
+	}
+	for _, player := range replay.Players {
+		player := player
+		game.Players[player.UserId] = &player
+	}
+
+	err := user.Conn.Send(Spectate{GameId: replay.GameId})
+	HandleError(err)
+	err = user.Conn.Send(GameStarted{
+		GameInfo: game,
+		Date:     game.EndDate(),
+	})
+	HandleError(err)
+
+	// the first state is a keyframe, every tick after it is based on the previous one
+	initial := StateSnapshot{Grid: replay.Grid, Players: make(map[UserId]PlayerState)}
+	for _, player := range replay.Players {
+		initial.Players[player.UserId] = PlayerState{
+			UserId:   player.UserId,
+			Position: player.Position,
+			Lives:    player.Lives,
+			Powerups: player.Powerups,
+		}
+	}
+	err = user.Conn.Send(initial.Delta(nil))
+	HandleError(err)
+
+	ticker := time.NewTicker(time.Second / time.Duration(replay.Config.TickRate))
+	defer ticker.Stop()
+
+	watching := func() bool {
+		if !GlobalClients.Exists(user.UserId) || user.Conn.Closed() {
+			return false
+		}
+		return GlobalGames.UserGame(user) == "global"
+	}
+
+	baseTick := 0
+	for _, delta := range replay.Ticks {
+		for tick := baseTick; tick < delta.Tick; tick++ {
+			<-ticker.C
+		}
+		if !watching() {
+			return
+		}
+
+		delta.BaseTick = baseTick
+		err = user.Conn.Send(delta)
+		HandleError(err)
+		baseTick = delta.Tick
+	}
+
+	if replay.Result != nil {
+		err = user.Conn.Send(*replay.Result)
+		HandleError(err)
+	}
+
+	// give the client time to show the result, like after a real game
+	time.Sleep(5 * time.Second)
+	if !watching() {
+		return
+	}
+	// the name and color of the user are changed by the handlers of the game they are in
+	_, unlock := LockUser(user)
+	defer unlock()
+	err = user.Conn.Send(LobbyLeft{
+		Username: user.Username,
+		Color:    user.Color,
+		Message:  "Replay ended",
+		Date:     CurrentTime(),
+		InGame:   true,
+	})
+	HandleError(err)
+}
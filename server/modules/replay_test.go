@@ -0,0 +1,118 @@
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// useReplayDir saves the replays of the test to an empty directory and resets the replay index
+func useReplayDir(t *testing.T) {
+	dir, pageSize := ReplayDir, ReplayPageSize
+	ReplayDir = t.TempDir()
+	replayIndex.loaded, replayIndex.replays = false, nil
+	t.Cleanup(func() {
+		ReplayDir, ReplayPageSize = dir, pageSize
+		replayIndex.loaded, replayIndex.replays = false, nil
+	})
+}
+
+func replayIds(replays []ReplayInfo) []string {
+	ids := []string{}
+	for _, replay := range replays {
+		ids = append(ids, replay.ReplayId)
+	}
+	return ids
+}
+
+func TestReplayPage(t *testing.T) {
+	useReplayDir(t)
+	ReplayPageSize = 2
+
+	for i := 1; i <= 4; i++ {
+		replay := Replay{
+			ReplayId: fmt.Sprint("replay-", i),
+			Date:     fmt.Sprintf("2024-01-0%d 12:00:00", i),
+			Players:  []User{{Username: "alice"}},
+		}
+		replay.Save()
+	}
+	// replays saved before the info files existed are listed as well
+	legacy, err := json.Marshal(Replay{ReplayId: "replay-0", Date: "2024-01-01 00:00:00"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(ReplayDir, "replay-0.json"), legacy, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		page  int
+		want  []string
+		found int
+	}{
+		{"first page", 0, []string{"replay-4", "replay-3"}, 0},
+		{"second page", 1, []string{"replay-2", "replay-1"}, 1},
+		{"last page", 2, []string{"replay-0"}, 2},
+		{"after the last page", 9, []string{"replay-0"}, 2},
+		{"negative page", -1, []string{"replay-4", "replay-3"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ReplayPage(tt.page)
+			if ids := replayIds(got.Replays); !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("replays = %v, want %v", ids, tt.want)
+			}
+			if got.Page != tt.found || got.Pages != 3 {
+				t.Errorf("page %d of %d, want page %d of 3", got.Page, got.Pages, tt.found)
+			}
+		})
+	}
+
+	if _, err := os.Stat(filepath.Join(ReplayDir, "replay-0.info.json")); err != nil {
+		t.Errorf("legacy replay didn't get an info file: %v", err)
+	}
+
+	// replays saved after the index was read are added to it
+	replay := Replay{ReplayId: "replay-5", Date: "2024-01-05 12:00:00"}
+	replay.Save()
+	if ids := replayIds(ReplayPage(0).Replays); !reflect.DeepEqual(ids, []string{"replay-5", "replay-4"}) {
+		t.Errorf("replays after saving = %v, want the new replay first", ids)
+	}
+}
+
+func TestReplayRecordsDrops(t *testing.T) {
+	for _, mode := range []DropMode{FixedDrops, WeightedDrops, FairDrops} {
+		t.Run(string(mode), func(t *testing.T) {
+			config := NewGameConfig()
+			config.DropMode = mode
+			game, _ := newTestLobby(t, config, 2)
+			game.BeginRound()
+
+			var barrels []Position
+			for y, row := range game.Grid {
+				for x, tile := range row {
+					if tile == config.GridConfig.BarrelBlock {
+						barrels = append(barrels, Position{X: x, Y: y})
+					}
+				}
+			}
+
+			var want []RecordedDrop
+			for i, pos := range barrels[:3] {
+				game.Tick = i
+				name := game.BarrelPowerup(pos)
+				want = append(want, RecordedDrop{Tick: i, Position: pos, Powerup: name})
+			}
+
+			if !reflect.DeepEqual(game.replay.Drops, want) {
+				t.Errorf("recorded drops = %v, want %v", game.replay.Drops, want)
+			}
+		})
+	}
+}
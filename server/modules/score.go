@@ -0,0 +1,84 @@
+package modules
+
+import (
+	"reflect"
+	"sort"
+)
+
+// ScoreEntry is the row of one player on the scoreboard
+type ScoreEntry struct {
+	UserId   UserId
+	Username string
+	Color    string
+	MatchStats
+}
+
+// CreditKill records lost lives in the stats of the player and the killer, lives lost to the players own bombs are self destructs
+func (game *Game) CreditKill(user *User, lives int, killerId UserId) {
+	user.Stats.Deaths += lives
+
+	switch killerId {
+	case "":
+	case user.UserId:
+		user.Stats.SelfDestructs += lives
+	default:
+		if killer := game.Participant(killerId); killer != nil {
+			killer.Stats.Kills += lives
+		}
+	}
+}
+
+// ExplosionOwner returns the owner of the newest explosion covering the tile
+func (game *Game) ExplosionOwner(tile Position) UserId {
+	for i := len(game.explosions) - 1; i >= 0; i-- {
+		explosion := game.explosions[i]
+		if game.Tick-explosion.StartTick >= game.Ticks(explosionTime) {
+			continue
+		}
+		for _, direction := range explosion.Bomb.ExplosionArea {
+			for _, pos := range direction {
+				if pos == tile {
+					return explosion.Bomb.UserId
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// Scoreboard returns the stats of every player, sorted by kills and then by deaths
+func (game *Game) Scoreboard() []ScoreEntry {
+	scoreboard := []ScoreEntry{}
+	for _, player := range GlobalGames.ListGamePlayers(game.GameId) {
+		scoreboard = append(scoreboard, ScoreEntry{
+			UserId:     player.UserId,
+			Username:   player.Username,
+			Color:      player.Color,
+			MatchStats: player.Stats,
+		})
+	}
+
+	sort.SliceStable(scoreboard, func(i, j int) bool {
+		if scoreboard[i].Kills != scoreboard[j].Kills {
+			return scoreboard[i].Kills > scoreboard[j].Kills
+		}
+		if scoreboard[i].Deaths != scoreboard[j].Deaths {
+			return scoreboard[i].Deaths < scoreboard[j].Deaths
+		}
+		return scoreboard[i].Username < scoreboard[j].Username
+	})
+
+	return scoreboard
+}
+
+// UpdateScoreboard sends the scoreboard to the game with the state of the tick if it has changed
+func (game *Game) UpdateScoreboard() {
+	scoreboard := game.Scoreboard()
+	if reflect.DeepEqual(scoreboard, game.scoreboard) {
+		return
+	}
+
+	game.scoreboard = scoreboard
+	game.Emit(Scoreboard{Players: scoreboard})
+}
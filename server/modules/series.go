@@ -0,0 +1,113 @@
+package modules
+
+import (
+	"time"
+)
+
+const maxRounds = 9
+
+// roundBreak is how long the result of a round is shown before the next round starts or the lobby returns
+var roundBreak = 5 * time.Second
+
+// SeriesState is the standing of a best-of series after a round
+type SeriesState struct {
+	Round     int
+	Rounds    int
+	RoundWins map[UserId]int
+	Over      bool
+	Winners   []User // Players with the most round wins once the series is over
+}
+
+// RecordRound adds a round win to the winners of the round and returns whether the series is over.
+// Tied rounds don't count for anyone, the series is over once a player has won the majority of the rounds or all rounds are played.
+func (game *Game) RecordRound(result string, winners []User) bool {
+	if result == "win" {
+		for _, winner := range winners {
+			game.RoundWins[winner.UserId]++
+		}
+	}
+
+	for _, wins := range game.RoundWins {
+		if wins > game.Config.Rounds/2 {
+			return true
+		}
+	}
+	return game.Round >= game.Config.Rounds
+}
+
+// SeriesState returns the standing of the series, the winners are only filled in once it is over.
+// A series without won rounds is drawn and has no winners.
+func (game *Game) SeriesState(over bool) SeriesState {
+	series := SeriesState{
+		Round:     game.Round,
+		Rounds:    game.Config.Rounds,
+		RoundWins: make(map[UserId]int),
+		Over:      over,
+	}
+	for userId, wins := range game.RoundWins {
+		series.RoundWins[userId] = wins
+	}
+	if !over {
+		return series
+	}
+
+	mostWins := 0
+	for _, player := range GlobalGames.ListGamePlayers(game.GameId) {
+		wins := game.RoundWins[player.UserId]
+		if wins > mostWins {
+			mostWins = wins
+			series.Winners = nil
+		}
+		if wins == mostWins && wins > 0 {
+			series.Winners = append(series.Winners, player)
+		}
+	}
+
+	return series
+}
+
+// NextRound regenerates the grid and starts the next round of the series with the players still in the game.
+// The series ends early when there are too few players left to play it.
+func (game *Game) NextRound() {
+	players := GlobalGames.ListGamePlayers(game.GameId)
+	if len(players) < minMatchSize || (game.Config.TeamMode && len(game.Teams()) < teamCount) {
+		game.ReturnToLobby()
+		return
+	}
+
+	game.Reconfigure(game.Config)
+	for _, player := range players {
+		game.ResetPlayer(GlobalClients.GetUser(player.UserId))
+	}
+	game.Status = InLobby
+
+	StartGame(GlobalClients.GetUser(players[0].UserId))
+}
+
+// ReturnToLobby ends the series, the players are back in the same lobby with a new grid and have to get ready again
+func (game *Game) ReturnToLobby() {
+	game.DismissSpectators()
+
+	game.Reconfigure(game.Config)
+	game.Status = InLobby
+	game.Round = 0
+	game.RoundWins = make(map[UserId]int)
+
+	for _, player := range GlobalGames.ListGamePlayers(game.GameId) {
+		user := GlobalClients.GetUser(player.UserId)
+		game.ResetPlayer(user)
+		user.ReadyState = false
+	}
+
+	lobby := game.LobbyState()
+	for _, player := range lobby.Users {
+		err := player.Conn.Send(LobbyJoined{
+			LobbyState: lobby,
+			Username:   player.Username,
+			Message:    "Back in the lobby",
+			Color:      player.Color,
+			UserId:     player.UserId,
+		})
+		HandleError(err)
+	}
+}
@@ -0,0 +1,141 @@
+package modules
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordRound(t *testing.T) {
+	tests := []struct {
+		name    string
+		rounds  int
+		results []string // result of each round, the first player wins the rounds which aren't ties
+		over    []bool
+	}{
+		{"single round", 1, []string{"win"}, []bool{true}},
+		{"single tied round", 1, []string{"tie"}, []bool{true}},
+		{"best of three won in two", 3, []string{"win", "win"}, []bool{false, true}},
+		{"ties don't count", 3, []string{"tie", "win", "tie"}, []bool{false, false, true}},
+		{"ties until the last round", 5, []string{"tie", "tie", "tie", "win", "win"}, []bool{false, false, false, false, true}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := NewGameConfig()
+			config.Rounds = test.rounds
+			game, users := newTestLobby(t, config, 2)
+
+			for i, result := range test.results {
+				game.Round++
+				var winners []User
+				if result == "win" {
+					winners = []User{*users[0]}
+				}
+				if over := game.RecordRound(result, winners); over != test.over[i] {
+					t.Fatalf("series over after round %d = %v, want %v", i+1, over, test.over[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSeriesContinuesAfterDoubleKnockout(t *testing.T) {
+	breakTime := roundBreak
+	roundBreak = 10 * time.Millisecond
+	t.Cleanup(func() { roundBreak = breakTime })
+
+	config := NewGameConfig()
+	config.Rounds = 3
+	game, users := newTestLobby(t, config, 2)
+
+	unlock := LockGames(game.GameId)
+	StartGame(users[0])
+	for _, user := range users {
+		game.LoseLife(user, user.Lives, "")
+	}
+	unlock()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		unlock := LockGames(game.GameId)
+		round, status := game.Round, game.Status
+		unlock()
+		if round == 2 && status == InGame {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("series stuck in round %d with status %v", round, status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	unlock = LockGames(game.GameId)
+	defer unlock()
+	for _, user := range users {
+		if user.Lives != game.Config.Lives || user.Spectator {
+			t.Errorf("%s starts the next round with %d lives, spectator %v", user.Username, user.Lives, user.Spectator)
+		}
+	}
+	game.Status = GameEnded
+}
+
+func TestJoinDuringRoundBreak(t *testing.T) {
+	config := NewGameConfig()
+	config.Rounds = 3
+	game, users := newTestLobby(t, config, 2)
+	game.BeginRound()
+	game.LoseLife(users[1], users[1].Lives, "")
+	GameOver(game.GameId)
+
+	late := newTestUser(t, "late")
+	JoinLobby(late, game.GameId)
+
+	if GlobalGames.PlayerExists(game.GameId, late.UserId) {
+		t.Fatal("user joined as a player in the middle of the series")
+	}
+	if !late.Spectator || late.GameId != string(game.GameId) {
+		t.Fatalf("user isn't spectating the series: spectator %v, game %s", late.Spectator, late.GameId)
+	}
+}
+
+func TestSeriesWinners(t *testing.T) {
+	tests := []struct {
+		name    string
+		wins    []int // round wins of each player
+		over    bool
+		winners []int
+	}{
+		{"series not over", []int{2, 0, 0}, false, nil},
+		{"most wins", []int{2, 1, 0}, true, []int{0}},
+		{"tied for most wins", []int{1, 1, 0}, true, []int{0, 1}},
+		{"no rounds won", []int{0, 0, 0}, true, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := NewGameConfig()
+			config.Rounds = 3
+			game, users := newTestLobby(t, config, 3)
+			for i, wins := range test.wins {
+				if wins > 0 {
+					game.RoundWins[users[i].UserId] = wins
+				}
+			}
+
+			series := game.SeriesState(test.over)
+
+			winners := make(map[UserId]bool)
+			for _, winner := range series.Winners {
+				winners[winner.UserId] = true
+			}
+			if len(winners) != len(test.winners) {
+				t.Errorf("%d winners, want %d", len(winners), len(test.winners))
+			}
+			for _, i := range test.winners {
+				if !winners[users[i].UserId] {
+					t.Errorf("player %d didn't win the series", i)
+				}
+			}
+		})
+	}
+}
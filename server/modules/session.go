@@ -0,0 +1,196 @@
+package modules
+
+import (
+	"bomberman_dom/server/logger"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// SessionGracePeriod is how long a disconnected user is kept in their lobby or game waiting for them to resume the session
+var SessionGracePeriod = 30 * time.Second
+
+// NewSession issues a session token for the user which can be used to resume the session on a new connection
+func NewSession(user *User) {
+	if user.SessionToken != "" {
+		GlobalSessions.Del(user.SessionToken)
+	}
+
+	user.SessionToken = uuid.NewString()
+	GlobalSessions.Add(user.SessionToken, &Session{UserId: user.UserId})
+}
+
+// Disconnect keeps a user with a session in the grace state for SessionGracePeriod, users without a session are removed right away.
+// Their character stays idle in the game until they resume or the grace period ends.
+func Disconnect(user *User) {
+	GlobalSessions.Lock()
+	session, ok := GlobalSessions.Data[user.SessionToken]
+	if !ok {
+		GlobalSessions.Unlock()
+		RemoveUser(user)
+		return
+	}
+	defer GlobalSessions.Unlock()
+
+	// the session was already resumed on a new connection
+	if !user.Conn.Closed() {
+		return
+	}
+
+	logger.Log(user.Username + " disconnected, keeping the session for " + SessionGracePeriod.String())
+
+	var expiry *time.Timer
+	expiry = time.AfterFunc(SessionGracePeriod, func() {
+		GlobalSessions.Lock()
+		// the session might have been resumed while the timer fired
+		if session.expiry != expiry {
+			GlobalSessions.Unlock()
+			return
+		}
+		delete(GlobalSessions.Data, user.SessionToken)
+		GlobalSessions.Unlock()
+
+		logger.Log(user.Username + "'s session expired")
+		_, unlock := LockUser(user, "global")
+		defer unlock()
+		RemoveUser(user)
+	})
+	session.expiry = expiry
+}
+
+// Resume reattaches a users session to the websocket of a new connection, returns nil if the token doesn't belong to a session
+func Resume(token string, newConn *Connection) *User {
+	GlobalSessions.Lock()
+	defer GlobalSessions.Unlock()
+
+	session, ok := GlobalSessions.Data[token]
+	if !ok || token == "" {
+		return nil
+	}
+	user := GlobalClients.GetUser(session.UserId)
+	if user == nil {
+		return nil
+	}
+
+	if session.expiry != nil {
+		session.expiry.Stop()
+		session.expiry = nil
+	}
+
+	oldConn := user.Conn.Reattach(newConn)
+	if oldConn != nil {
+		oldConn.Close()
+	}
+
+	return user
+}
+
+// RemoveUser removes the user from their lobby, the global chat and the list of clients
+func RemoveUser(user *User) {
+	Matchmaking.Remove(user.UserId)
+	LeaveLobby(user)
+	// if current game was not global then remove player from global 'game' (lobby) as well
+	if user.GameId != "global" {
+		GlobalGames.SetUserGame(user, "global")
+		LeaveLobby(user)
+	}
+
+	GlobalClients.Del(user.UserId)
+}
+
+// Resync sends a resumed user the state they have missed: their user, the lobby they are in and the running game
+func Resync(user *User) {
+	err := user.Conn.Send(UserCreated{
+		Username:     user.Username,
+		Color:        user.Color,
+		UserId:       user.UserId,
+		SessionToken: user.SessionToken,
+		ProfileId:    user.ProfileId,
+		AccountToken: user.AccountToken,
+	})
+	HandleError(err)
+
+	game := GlobalGames.GetGame(GameId(user.GameId))
+	if game == nil || game.GameId == "global" {
+		return
+	}
+
+	if user.Spectator {
+		err = user.Conn.Send(Spectate{
+			GameId:     game.GameId,
+			Eliminated: GlobalGames.PlayerExists(game.GameId, user.UserId),
+		})
+		HandleError(err)
+	} else {
+		err = user.Conn.Send(LobbyJoined{
+			LobbyState: game.LobbyState(),
+			Username:   user.Username,
+			Color:      user.Color,
+			UserId:     user.UserId,
+		})
+		HandleError(err)
+	}
+
+	// the game state is read by the game loop, so the game info is sent on the next tick
+	QueueInput(user, resyncInput{})
+}
+
+// resyncInput asks the game loop to send the full game info to a resumed user
+type resyncInput struct{}
+
+// SendGameInfo sends the current game info to the user, the next game state they receive is a keyframe
+func (game *Game) SendGameInfo(user *User) {
+	user.Conn.Ack(0)
+	err := user.Conn.Send(GameStarted{
+		GameInfo: game.PrepareForSend(),
+		Date:     game.EndDate(),
+	})
+	HandleError(err)
+}
+
+// Reattach moves the websocket of the new connection and its writer to the connection, and returns the previous websocket if it was still open
+func (conn *Connection) Reattach(newConn *Connection) *websocket.Conn {
+	newConn.mut.Lock()
+	wsconn, codec, outbound := newConn.Conn, newConn.Codec, newConn.outbound
+	newConn.outbound = nil
+	newConn.closed = true
+	newConn.mut.Unlock()
+
+	conn.mut.Lock()
+	defer conn.mut.Unlock()
+
+	oldConn := conn.Conn
+	if conn.closed {
+		oldConn = nil
+	}
+	conn.stopWriter()
+	conn.Conn = wsconn
+	conn.Codec = codec
+	conn.outbound = outbound
+	conn.closed = false
+
+	return oldConn
+}
+
+// Detach stops the writer of the websocket and marks the connection as closed, so nothing is sent to it until it's reattached.
+// Returns false if the connection has already been reattached to another websocket.
+func (conn *Connection) Detach(wsconn *websocket.Conn) bool {
+	conn.mut.Lock()
+	defer conn.mut.Unlock()
+
+	if conn.Conn != wsconn {
+		return false
+	}
+	conn.stopWriter()
+	conn.closed = true
+
+	return true
+}
+
+// Closed returns a boolean indicating whether the connection is waiting to be reattached
+func (conn *Connection) Closed() bool {
+	conn.mut.Lock()
+	defer conn.mut.Unlock()
+	return conn.closed
+}
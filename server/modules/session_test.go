@@ -0,0 +1,81 @@
+package modules
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionResume(t *testing.T) {
+	grace := SessionGracePeriod
+	SessionGracePeriod = 50 * time.Millisecond
+	t.Cleanup(func() { SessionGracePeriod = grace })
+
+	tests := []struct {
+		name    string
+		wait    time.Duration // time between the disconnect and the resume
+		resumed bool
+	}{
+		{"inside the grace period", 0, true},
+		{"after the grace period", 200 * time.Millisecond, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			game, users := newTestLobby(t, NewGameConfig(), 2)
+			user := users[0]
+			NewSession(user)
+			t.Cleanup(func() { GlobalSessions.Del(user.SessionToken) })
+
+			Disconnect(user)
+			time.Sleep(test.wait)
+
+			resumed := Resume(user.SessionToken, &Connection{})
+			if (resumed == user) != test.resumed {
+				t.Fatalf("resumed user = %v, want resumed %t", resumed, test.resumed)
+			}
+
+			// the expiry of a resumed session has been stopped
+			time.Sleep(2 * SessionGracePeriod)
+			if GlobalClients.Exists(user.UserId) != test.resumed {
+				t.Errorf("user kept = %t, want %t", GlobalClients.Exists(user.UserId), test.resumed)
+			}
+			if GlobalGames.PlayerExists(game.GameId, user.UserId) != test.resumed {
+				t.Errorf("user kept in the lobby = %t, want %t", GlobalGames.PlayerExists(game.GameId, user.UserId), test.resumed)
+			}
+		})
+	}
+}
+
+func TestSessionExpiry(t *testing.T) {
+	grace := SessionGracePeriod
+	SessionGracePeriod = 10 * time.Millisecond
+	t.Cleanup(func() { SessionGracePeriod = grace })
+
+	game, users := newTestLobby(t, NewGameConfig(), 2)
+	user := users[0]
+	NewSession(user)
+	token := user.SessionToken
+
+	Disconnect(user)
+	if !GlobalClients.Exists(user.UserId) {
+		t.Fatal("user removed before the grace period ended")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for GlobalClients.Exists(user.UserId) {
+		if time.Now().After(deadline) {
+			t.Fatal("session didn't expire")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if GlobalSessions.GetSession(token) != nil {
+		t.Error("expired session can still be resumed")
+	}
+	if GlobalGames.PlayerExists(game.GameId, user.UserId) {
+		t.Error("user of the expired session is still in the lobby")
+	}
+	if !GlobalGames.PlayerExists(game.GameId, users[1].UserId) {
+		t.Error("other player was removed from the lobby")
+	}
+}
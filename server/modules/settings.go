@@ -0,0 +1,259 @@
+package modules
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	minLives         = 1
+	maxLives         = 9
+	minGridSize      = 9
+	maxGridWidth     = 25
+	maxGridHeight    = 21
+	minMatchDuration = time.Minute
+	maxMatchDuration = 10 * time.Minute
+	minPlayers       = 2
+	maxPlayers       = 8
+	defaultPlayers   = 4
+	minSpawnGridSize = 11 // Smallest grid width and height with room for the spawn points of more than 4 players
+)
+
+// LobbySettings are the fields of the GameConfig the lobby host can change
+type LobbySettings struct {
+	Lives          int
+	MaxPlayers     int
+	Width          int
+	Height         int
+	FillPercentage float64
+	Powerups       map[PowerupName]int // How many barrels contain each powerup, powerups left out keep their amount
+	Timing         string              // Name of the timing profile, choosing another profile replaces the durations below
+	MatchDuration  int                 // Seconds
+	ShrinkStart    int                 // Seconds from the start of the match until the grid starts shrinking
+	Rounds         int                 // Rounds of the best-of series
+	TeamMode       bool
+	FriendlyFire   bool
+}
+
+// Settings returns the lobby settings of the config
+func (config GameConfig) Settings() LobbySettings {
+	settings := LobbySettings{
+		Lives:          config.Lives,
+		MaxPlayers:     config.MaxPlayers(),
+		Width:          config.GridConfig.Width,
+		Height:         config.GridConfig.Height,
+		FillPercentage: config.GridConfig.FillPercentage,
+		Powerups:       make(map[PowerupName]int),
+		Timing:         config.Timing.Name,
+		MatchDuration:  int(config.Timing.MatchDuration / time.Second),
+		ShrinkStart:    int(config.Timing.ShrinkStart / time.Second),
+		Rounds:         config.Rounds,
+		TeamMode:       config.TeamMode,
+		FriendlyFire:   config.FriendlyFire,
+	}
+
+	for name, powerup := range config.Powerups {
+		if name != "Nothing" {
+			settings.Powerups[name] = powerup.Amount
+		}
+	}
+
+	return settings
+}
+
+// ApplySettings returns a copy of the config with the lobby settings applied, or an error if they are out of bounds.
+// Changing the player capacity also resizes the grid to the default size for it, unless the grid size was changed as well.
+func (config GameConfig) ApplySettings(settings LobbySettings) (GameConfig, error) {
+	config.Lives = settings.Lives
+	config.GridConfig.Width = settings.Width
+	config.GridConfig.Height = settings.Height
+	if settings.MaxPlayers != config.MaxPlayers() {
+		width, height := DefaultGridSize(config.MaxPlayers())
+		if settings.Width == width && settings.Height == height {
+			config.GridConfig.Width, config.GridConfig.Height = DefaultGridSize(settings.MaxPlayers)
+		}
+		config.GridConfig.Players = settings.MaxPlayers
+	}
+	config.GridConfig.FillPercentage = settings.FillPercentage
+	config.Rounds = settings.Rounds
+	config.TeamMode = settings.TeamMode
+	config.FriendlyFire = settings.FriendlyFire
+	if settings.Timing != config.Timing.Name {
+		timing, ok := TimingProfiles[settings.Timing]
+		if !ok {
+			return config, fmt.Errorf("unknown timing profile '%s'", settings.Timing)
+		}
+		config.Timing = timing
+	} else {
+		config.Timing.MatchDuration = time.Duration(settings.MatchDuration) * time.Second
+		config.Timing.ShrinkStart = time.Duration(settings.ShrinkStart) * time.Second
+	}
+
+	config.Powerups = CopyPowerups(config.Powerups)
+	for name, amount := range settings.Powerups {
+		powerup, ok := config.Powerups[name]
+		if !ok || name == "Nothing" {
+			return config, fmt.Errorf("unknown powerup '%s'", name)
+		}
+		powerup.Amount = amount
+		config.Powerups[name] = powerup
+	}
+
+	return config, config.CheckBounds()
+}
+
+// CheckBounds returns an error if a field the lobby host can change is out of its bounds
+func (config GameConfig) CheckBounds() error {
+	grid := config.GridConfig
+	timing := config.Timing
+
+	if config.Lives < minLives || config.Lives > maxLives {
+		return fmt.Errorf("lives have to be between %d and %d", minLives, maxLives)
+	}
+	if config.MaxPlayers() < minPlayers || config.MaxPlayers() > maxPlayers {
+		return fmt.Errorf("player capacity has to be between %d and %d", minPlayers, maxPlayers)
+	}
+	if config.Rounds < 1 || config.Rounds > maxRounds {
+		return fmt.Errorf("rounds have to be between 1 and %d", maxRounds)
+	}
+	if grid.Width < minGridSize || grid.Width > maxGridWidth || grid.Width%2 == 0 {
+		return fmt.Errorf("grid width has to be an odd number between %d and %d", minGridSize, maxGridWidth)
+	}
+	if grid.Height < minGridSize || grid.Height > maxGridHeight || grid.Height%2 == 0 {
+		return fmt.Errorf("grid height has to be an odd number between %d and %d", minGridSize, maxGridHeight)
+	}
+	if config.MaxPlayers() > 4 && (grid.Width < minSpawnGridSize || grid.Height < minSpawnGridSize) {
+		return fmt.Errorf("more than 4 players need a grid of at least %dx%d", minSpawnGridSize, minSpawnGridSize)
+	}
+	if grid.FillPercentage < 0 || grid.FillPercentage > 1 {
+		return errors.New("fill percentage has to be between 0 and 1")
+	}
+	if timing.MatchDuration < minMatchDuration || timing.MatchDuration > maxMatchDuration {
+		return fmt.Errorf("match duration has to be between %s and %s", minMatchDuration, maxMatchDuration)
+	}
+
+	// the outer circles have to be shrunk before the inner area starts shrinking at the end of the match
+	innerArea := (grid.Width - 6) * (grid.Height - 6)
+	innerShrinkStart := timing.MatchDuration - time.Duration(innerArea)*timing.InnerShrinkStep
+	if timing.ShrinkStart < 0 || timing.ShrinkStart+timing.OuterShrinkDuration > innerShrinkStart {
+		return fmt.Errorf("shrinking has to start between 0s and %s", (innerShrinkStart - timing.OuterShrinkDuration).Truncate(time.Second))
+	}
+	if timing.SuddenDeath.Start >= timing.MatchDuration {
+		return fmt.Errorf("the match has to last longer than %s for sudden death", timing.SuddenDeath.Start)
+	}
+
+	powerupAmount := 0
+	for _, powerup := range config.Powerups {
+		if powerup.Amount < 0 {
+			return fmt.Errorf("amount of %s can't be negative", powerup.Name)
+		}
+		powerupAmount += powerup.Amount
+	}
+	if powerupAmount > grid.BarrelAmount() {
+		return fmt.Errorf("%d powerups don't fit in %d barrels", powerupAmount, grid.BarrelAmount())
+	}
+
+	return nil
+}
+
+// UpdateLobbySettings changes the settings of the lobby of the host, everyone in the lobby has to get ready again
+func UpdateLobbySettings(user *User, msg UpdateLobbySettingsInput) {
+	game := GlobalGames.GetGame(GameId(user.GameId))
+	if game.GameId == "global" || game.Status != InLobby {
+		sendLobbyError(user, "Settings can only be changed in a lobby!")
+		return
+	}
+	if game.HostId != user.UserId {
+		sendLobbyError(user, "Only the lobby host can change the settings!")
+		return
+	}
+
+	config, err := game.Config.ApplySettings(msg.LobbySettings)
+	if err != nil {
+		sendLobbyError(user, "Invalid settings: "+err.Error())
+		return
+	}
+	if players := len(GlobalGames.ListGamePlayers(game.GameId)); players > config.MaxPlayers() {
+		sendLobbyError(user, fmt.Sprintf("Invalid settings: %d players are already in the lobby", players))
+		return
+	}
+	// the teams are filled again when team mode is switched or the teams get another size
+	teamsChanged := game.Config.TeamMode != config.TeamMode || (config.TeamMode && game.Config.MaxPlayers() != config.MaxPlayers())
+	game.Reconfigure(config)
+	if teamsChanged {
+		game.AssignTeams()
+	}
+
+	for _, player := range GlobalGames.ListGamePlayers(game.GameId) {
+		player := GlobalClients.GetUser(player.UserId)
+		player.ReadyState = false
+		player.Lives = config.Lives
+	}
+
+	err = GlobalGames.BroadcastToGame(game.GameId, LobbySettingsChanged{
+		LobbyState: game.LobbyState(),
+		Message:    user.Username + " changed the lobby settings",
+		Date:       CurrentTime(),
+	})
+	HandleError(err)
+}
+
+// Reconfigure replaces the config of a game in the lobby and generates a new grid for it, the bombs and explosions of the last round are cleared
+func (game *Game) Reconfigure(config GameConfig) {
+	config.GameId = game.GameId
+	fresh := NewGame(config)
+
+	game.Config = fresh.Config
+	game.Grid = fresh.Grid
+	game.ActivePowerUps = fresh.ActivePowerUps
+	game.ActiveExplosions = fresh.ActiveExplosions
+	game.BarrelsBroken = 0
+	game.BarrelContents = fresh.BarrelContents
+	game.barrelPowerups = fresh.barrelPowerups
+	game.bombTiles = fresh.bombTiles
+	game.bombs = nil
+	game.explosions = nil
+	game.shrunkTiles = 0
+
+	game.inputsMut.Lock()
+	game.inputs = nil
+	game.inputsMut.Unlock()
+}
+
+// LobbyState returns the lobby code, host, settings and everyone in the lobby
+func (game *Game) LobbyState() LobbyState {
+	return LobbyState{
+		GameId:   game.GameId,
+		HostId:   game.HostId,
+		Settings: game.Config.Settings(),
+		Users:    GlobalGames.ListGamePlayers(game.GameId),
+	}
+}
+
+// PassHost makes the player who has been in the lobby the longest the host, if the host has left
+func (game *Game) PassHost() {
+	if game.HostId == "" || GlobalGames.PlayerExists(game.GameId, game.HostId) {
+		return
+	}
+
+	players := GlobalGames.ListGamePlayers(game.GameId)
+	if len(players) == 0 {
+		game.HostId = ""
+		return
+	}
+	sort.Slice(players, func(i, j int) bool {
+		if players[i].Time != players[j].Time {
+			return players[i].Time < players[j].Time
+		}
+		return players[i].UserId < players[j].UserId
+	})
+	game.HostId = players[0].UserId
+}
+
+// sendLobbyError tells the user why their lobby action failed
+func sendLobbyError(user *User, message string) {
+	err := user.Conn.Send(LobbyError{Message: message})
+	HandleError(err)
+}
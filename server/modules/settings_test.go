@@ -0,0 +1,78 @@
+package modules
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckBounds(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(config *GameConfig)
+		want   string // part of the error, empty if the config is valid
+	}{
+		{"default config", func(config *GameConfig) {}, ""},
+		{"fewest lives", func(config *GameConfig) { config.Lives = minLives }, ""},
+		{"no lives", func(config *GameConfig) { config.Lives = 0 }, "lives"},
+		{"too many lives", func(config *GameConfig) { config.Lives = maxLives + 1 }, "lives"},
+		{"one player", func(config *GameConfig) { config.GridConfig.Players = 1 }, "player capacity"},
+		{"most players", func(config *GameConfig) { config.GridConfig = NewGridConfig(maxPlayers) }, ""},
+		{"too many players", func(config *GameConfig) { config.GridConfig.Players = maxPlayers + 1 }, "player capacity"},
+		{"no rounds", func(config *GameConfig) { config.Rounds = 0 }, "rounds"},
+		{"too many rounds", func(config *GameConfig) { config.Rounds = maxRounds + 1 }, "rounds"},
+		{"even width", func(config *GameConfig) { config.GridConfig.Width = 14 }, "grid width"},
+		{"narrow grid", func(config *GameConfig) { config.GridConfig.Width = minGridSize - 2 }, "grid width"},
+		{"wide grid", func(config *GameConfig) { config.GridConfig.Width = maxGridWidth + 2 }, "grid width"},
+		{"even height", func(config *GameConfig) { config.GridConfig.Height = 12 }, "grid height"},
+		{"tall grid", func(config *GameConfig) { config.GridConfig.Height = maxGridHeight + 2 }, "grid height"},
+		{"smallest grid without powerups", func(config *GameConfig) {
+			config.GridConfig.Width, config.GridConfig.Height = minGridSize, minGridSize
+			config.Timing.ShrinkStart = 0
+			for name, powerup := range config.Powerups {
+				powerup.Amount = 0
+				config.Powerups[name] = powerup
+			}
+		}, ""},
+		{"small grid for many players", func(config *GameConfig) {
+			config.GridConfig.Players = 6
+			config.GridConfig.Width, config.GridConfig.Height = minGridSize, minGridSize
+		}, "more than 4 players"},
+		{"negative fill", func(config *GameConfig) { config.GridConfig.FillPercentage = -0.1 }, "fill percentage"},
+		{"overfilled", func(config *GameConfig) { config.GridConfig.FillPercentage = 1.1 }, "fill percentage"},
+		{"short match", func(config *GameConfig) { config.Timing.MatchDuration = minMatchDuration - time.Second }, "match duration"},
+		{"long match", func(config *GameConfig) { config.Timing.MatchDuration = maxMatchDuration + time.Second }, "match duration"},
+		{"negative shrink start", func(config *GameConfig) { config.Timing.ShrinkStart = -time.Second }, "shrinking"},
+		{"late shrink start", func(config *GameConfig) { config.Timing.ShrinkStart = config.Timing.MatchDuration }, "shrinking"},
+		{"sudden death after the match", func(config *GameConfig) {
+			config.Timing.SuddenDeath.Start = config.Timing.MatchDuration
+		}, "sudden death"},
+		{"negative powerup amount", func(config *GameConfig) {
+			powerup := config.Powerups["Bomb"]
+			powerup.Amount = -1
+			config.Powerups["Bomb"] = powerup
+		}, "negative"},
+		{"more powerups than barrels", func(config *GameConfig) {
+			powerup := config.Powerups["Bomb"]
+			powerup.Amount = config.GridConfig.BarrelAmount() + 1
+			config.Powerups["Bomb"] = powerup
+		}, "don't fit"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := NewGameConfig()
+			test.change(&config)
+
+			err := config.CheckBounds()
+			switch {
+			case test.want == "" && err != nil:
+				t.Errorf("config rejected: %v", err)
+			case test.want != "" && err == nil:
+				t.Errorf("config accepted, want an error about %s", test.want)
+			case test.want != "" && !strings.Contains(err.Error(), test.want):
+				t.Errorf("error = %q, want an error about %s", err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package modules
+
+// JoinAsSpectator adds the user to a running game as a spectator, they receive the game state but can't play
+func JoinAsSpectator(user *User, game *Game) {
+	user.Time = CurrentTime()
+	GlobalGames.SetUserGame(user, game.GameId)
+	user.ReadyState = false
+	user.Spectator = true
+	GlobalGames.AddSpectator(game.GameId, user)
+
+	err := user.Conn.Send(ChatJoined{
+		Username: user.Username,
+		Color:    user.Color,
+		Message:  "Joined spectator chat",
+		Date:     CurrentTime(),
+	})
+	HandleError(err)
+
+	err = user.Conn.Send(Spectate{GameId: game.GameId})
+	HandleError(err)
+
+	// the game loop sends the game info on the next tick
+	QueueInput(user, resyncInput{})
+}
+
+// Eliminate makes a player who has lost all their lives a spectator of the game
+func (game *Game) Eliminate(user *User) {
+	if user.Spectator {
+		return
+	}
+	user.Spectator = true
+	user.Stats.EliminatedTick = game.Tick
+	game.DropPowerups(user)
+
+	err := user.Conn.Send(ChatJoined{
+		Username: user.Username,
+		Color:    user.Color,
+		Message:  "Joined spectator chat",
+		Date:     CurrentTime(),
+	})
+	HandleError(err)
+
+	err = user.Conn.Send(Spectate{
+		GameId:     game.GameId,
+		Eliminated: true,
+	})
+	HandleError(err)
+}
+
+// LeaveSpectating removes a spectator from the game they are watching, returns false if the user wasn't spectating
+func LeaveSpectating(user *User) bool {
+	if !GlobalGames.RemoveSpectator(GameId(user.GameId), user.UserId) {
+		return false
+	}
+	user.Spectator = false
+
+	err := user.Conn.Send(LobbyLeft{
+		Message:  user.Username + " left the spectator chat",
+		Username: user.Username,
+		Date:     CurrentTime(),
+		Color:    user.Color,
+		InGame:   true,
+	})
+	HandleError(err)
+
+	return true
+}
+
+// DismissSpectators sends everyone spectating the game back to the global chat
+func (game *Game) DismissSpectators() {
+	for _, spectator := range GlobalGames.ListGameSpectators(game.GameId) {
+		user := GlobalClients.GetUser(spectator.UserId)
+		if user == nil {
+			continue
+		}
+		LeaveSpectating(user)
+		JoinLobby(user, "global")
+	}
+}
@@ -0,0 +1,187 @@
+package modules
+
+import "sort"
+
+// TileChange is a grid tile whose value has changed compared to an earlier state
+type TileChange struct {
+	X     int
+	Y     int
+	Value int
+}
+
+// PlayerState contains the player fields that are synced to the clients on every tick
+type PlayerState struct {
+	UserId   UserId
+	Position Position
+	Lives    int
+	Powerups PlayerPowerUps
+}
+
+// PlayerDelta contains the player fields that have changed compared to an earlier state, unchanged fields are left empty
+type PlayerDelta struct {
+	UserId   UserId
+	Position *Position       `json:",omitempty"`
+	Lives    *int            `json:",omitempty"`
+	Powerups *PlayerPowerUps `json:",omitempty"`
+}
+
+// StateSnapshot is the synced state of a game at the end of a tick
+type StateSnapshot struct {
+	Tick    int
+	Grid    Grid
+	Players map[UserId]PlayerState
+	Events  []Envelope
+}
+
+// StateDelta is sent to clients on every tick, it contains the changes since the state on BaseTick and the events of the tick.
+// Keyframes contain every tile and player and don't depend on any earlier state.
+type StateDelta struct {
+	Tick     int
+	BaseTick int
+	Keyframe bool
+	Tiles    []TileChange
+	Players  []PlayerDelta
+	Removed  []UserId // Players of the base state who have left the game
+	Events   []Envelope
+}
+
+// Snapshot returns the synced state of the game on the current tick
+func (game *Game) Snapshot() *StateSnapshot {
+	safeGame := game.PrepareForSend()
+	snapshot := StateSnapshot{
+		Tick:    game.Tick,
+		Grid:    safeGame.Grid,
+		Players: make(map[UserId]PlayerState),
+		Events:  game.events,
+	}
+
+	for userId, player := range safeGame.Players {
+		snapshot.Players[userId] = PlayerState{
+			UserId:   player.UserId,
+			Position: player.Position,
+			Lives:    player.Lives,
+			Powerups: player.Powerups,
+		}
+	}
+
+	return &snapshot
+}
+
+// Delta returns the changes between the base snapshot and this one, a nil base returns a keyframe
+func (snapshot *StateSnapshot) Delta(base *StateSnapshot) StateDelta {
+	delta := StateDelta{
+		Tick:     snapshot.Tick,
+		Keyframe: base == nil,
+		Tiles:    []TileChange{},
+		Players:  []PlayerDelta{},
+		Removed:  []UserId{},
+		Events:   snapshot.Events,
+	}
+	if base != nil {
+		delta.BaseTick = base.Tick
+	}
+
+	for y, row := range snapshot.Grid {
+		for x, tile := range row {
+			if base == nil || base.Grid[y][x] != tile {
+				delta.Tiles = append(delta.Tiles, TileChange{X: x, Y: y, Value: tile})
+			}
+		}
+	}
+
+	for userId, player := range snapshot.Players {
+		player := player
+		basePlayer, ok := PlayerState{}, false
+		if base != nil {
+			basePlayer, ok = base.Players[userId]
+		}
+
+		playerDelta := PlayerDelta{UserId: userId}
+		changed := false
+		if !ok || basePlayer.Position != player.Position {
+			playerDelta.Position = &player.Position
+			changed = true
+		}
+		if !ok || basePlayer.Lives != player.Lives {
+			playerDelta.Lives = &player.Lives
+			changed = true
+		}
+		if !ok || basePlayer.Powerups != player.Powerups {
+			playerDelta.Powerups = &player.Powerups
+			changed = true
+		}
+
+		if changed {
+			delta.Players = append(delta.Players, playerDelta)
+		}
+	}
+
+	if base != nil {
+		for userId := range base.Players {
+			if _, ok := snapshot.Players[userId]; !ok {
+				delta.Removed = append(delta.Removed, userId)
+			}
+		}
+		sort.Slice(delta.Removed, func(i, j int) bool { return delta.Removed[i] < delta.Removed[j] })
+	}
+
+	return delta
+}
+
+// BroadcastState sends every game player the changes since the state they have acknowledged, together with the events of the current tick.
+// Every KeyframeInterval ticks, or when the acknowledged state is too old, a full keyframe is sent instead.
+func (game *Game) BroadcastState() {
+	snapshot := game.Snapshot()
+	game.history[snapshot.Tick] = snapshot
+	game.replay.RecordTick(snapshot)
+	delete(game.history, snapshot.Tick-game.Config.KeyframeInterval)
+
+	keyframe := snapshot.Tick%game.Config.KeyframeInterval == 0
+	// players which have acknowledged the same tick get the same delta
+	deltas := make(map[int]StateDelta)
+
+	// spectators get the same state stream as players
+	receivers := append(GlobalGames.ListGamePlayers(game.GameId), GlobalGames.ListGameSpectators(game.GameId)...)
+	for _, player := range receivers {
+		baseTick := player.Conn.AckedTick()
+		base, ok := game.history[baseTick]
+		if keyframe || !ok {
+			baseTick = -1
+			base = nil
+		}
+
+		delta, ok := deltas[baseTick]
+		if !ok {
+			delta = snapshot.Delta(base)
+			deltas[baseTick] = delta
+		}
+
+		err := player.Conn.Send(delta)
+		HandleError(err)
+	}
+
+	game.events = nil
+}
+
+// AcknowledgeState saves the last game tick the client has received the state of
+func AcknowledgeState(user *User, msg AckInput) {
+	// acknowledgements can still arrive for the previous game
+	if msg.GameId != user.GameId {
+		return
+	}
+	user.Conn.Ack(msg.Tick)
+}
+
+// Ack sets the last game tick acknowledged by the connection
+func (conn *Connection) Ack(tick int) {
+	conn.mut.Lock()
+	defer conn.mut.Unlock()
+	conn.ackedTick = tick
+}
+
+// AckedTick returns the last game tick acknowledged by the connection
+func (conn *Connection) AckedTick() int {
+	conn.mut.Lock()
+	defer conn.mut.Unlock()
+	return conn.ackedTick
+}
@@ -0,0 +1,175 @@
+package modules
+
+import (
+	"reflect"
+	"testing"
+)
+
+// applyDelta returns the base state with the changes of the delta, like the client applies them
+func applyDelta(base *StateSnapshot, delta StateDelta) *StateSnapshot {
+	state := &StateSnapshot{Tick: delta.Tick, Players: make(map[UserId]PlayerState)}
+	if base != nil && !delta.Keyframe {
+		for _, row := range base.Grid {
+			state.Grid = append(state.Grid, append([]int{}, row...))
+		}
+		for userId, player := range base.Players {
+			state.Players[userId] = player
+		}
+	}
+
+	for _, tile := range delta.Tiles {
+		for len(state.Grid) <= tile.Y {
+			state.Grid = append(state.Grid, []int{})
+		}
+		for len(state.Grid[tile.Y]) <= tile.X {
+			state.Grid[tile.Y] = append(state.Grid[tile.Y], 0)
+		}
+		state.Grid[tile.Y][tile.X] = tile.Value
+	}
+	for _, change := range delta.Players {
+		player := state.Players[change.UserId]
+		player.UserId = change.UserId
+		if change.Position != nil {
+			player.Position = *change.Position
+		}
+		if change.Lives != nil {
+			player.Lives = *change.Lives
+		}
+		if change.Powerups != nil {
+			player.Powerups = *change.Powerups
+		}
+		state.Players[change.UserId] = player
+	}
+	for _, userId := range delta.Removed {
+		delete(state.Players, userId)
+	}
+
+	return state
+}
+
+func TestStateSnapshotDelta(t *testing.T) {
+	player := PlayerState{UserId: "a", Position: Position{50, 50}, Lives: 3, Powerups: NewPlayerPowerUps()}
+	other := PlayerState{UserId: "b", Position: Position{578, 490}, Lives: 3, Powerups: NewPlayerPowerUps()}
+	base := &StateSnapshot{
+		Tick:    10,
+		Grid:    Grid{{1, 1, 1}, {1, 0, 2}, {1, 1, 1}},
+		Players: map[UserId]PlayerState{"a": player, "b": other},
+	}
+
+	moved := player
+	moved.Position.X += 4
+	hurt := other
+	hurt.Lives--
+	hurt.Powerups.Bombs++
+
+	tests := []struct {
+		name     string
+		base     *StateSnapshot
+		grid     Grid
+		players  []PlayerState
+		tiles    int
+		changed  map[UserId][]string // changed fields of each player in the delta
+		removed  []UserId
+		keyframe bool
+	}{
+		{
+			name:     "keyframe",
+			grid:     base.Grid,
+			players:  []PlayerState{player, other},
+			tiles:    9,
+			changed:  map[UserId][]string{"a": {"Position", "Lives", "Powerups"}, "b": {"Position", "Lives", "Powerups"}},
+			keyframe: true,
+		},
+		{
+			name:    "nothing changed",
+			base:    base,
+			grid:    base.Grid,
+			players: []PlayerState{player, other},
+			changed: map[UserId][]string{},
+		},
+		{
+			name:    "player moved",
+			base:    base,
+			grid:    base.Grid,
+			players: []PlayerState{moved, other},
+			changed: map[UserId][]string{"a": {"Position"}},
+		},
+		{
+			name:    "life lost and powerup picked up",
+			base:    base,
+			grid:    base.Grid,
+			players: []PlayerState{player, hurt},
+			changed: map[UserId][]string{"b": {"Lives", "Powerups"}},
+		},
+		{
+			name:    "barrel broken",
+			base:    base,
+			grid:    Grid{{1, 1, 1}, {1, 0, 0}, {1, 1, 1}},
+			players: []PlayerState{player, other},
+			tiles:   1,
+			changed: map[UserId][]string{},
+		},
+		{
+			name:    "player missing from the base",
+			base:    &StateSnapshot{Tick: 10, Grid: base.Grid, Players: map[UserId]PlayerState{"a": player}},
+			grid:    base.Grid,
+			players: []PlayerState{player, other},
+			changed: map[UserId][]string{"b": {"Position", "Lives", "Powerups"}},
+		},
+		{
+			name:    "player left",
+			base:    base,
+			grid:    base.Grid,
+			players: []PlayerState{player},
+			changed: map[UserId][]string{},
+			removed: []UserId{"b"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			snapshot := &StateSnapshot{Tick: 11, Grid: test.grid, Players: make(map[UserId]PlayerState)}
+			for _, player := range test.players {
+				snapshot.Players[player.UserId] = player
+			}
+
+			delta := snapshot.Delta(test.base)
+			if delta.Keyframe != test.keyframe {
+				t.Errorf("keyframe = %v, want %v", delta.Keyframe, test.keyframe)
+			}
+			if test.base != nil && delta.BaseTick != test.base.Tick {
+				t.Errorf("base tick = %d, want %d", delta.BaseTick, test.base.Tick)
+			}
+			if len(delta.Tiles) != test.tiles {
+				t.Errorf("%d changed tiles, want %d", len(delta.Tiles), test.tiles)
+			}
+
+			changed := make(map[UserId][]string)
+			for _, player := range delta.Players {
+				var fields []string
+				if player.Position != nil {
+					fields = append(fields, "Position")
+				}
+				if player.Lives != nil {
+					fields = append(fields, "Lives")
+				}
+				if player.Powerups != nil {
+					fields = append(fields, "Powerups")
+				}
+				changed[player.UserId] = fields
+			}
+			if !reflect.DeepEqual(changed, test.changed) {
+				t.Errorf("changed players = %v, want %v", changed, test.changed)
+			}
+			if len(delta.Removed) != 0 || len(test.removed) != 0 {
+				if !reflect.DeepEqual(delta.Removed, test.removed) {
+					t.Errorf("removed players = %v, want %v", delta.Removed, test.removed)
+				}
+			}
+
+			if applied := applyDelta(test.base, delta); !reflect.DeepEqual(applied, snapshot) {
+				t.Errorf("base with the delta applied = %+v, want %+v", applied, snapshot)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package modules
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound is returned by the storage when a record doesn't exist
+var ErrNotFound = errors.New("not found")
+
+// Storage holds the player profiles and match history, set when the server starts
+var Storage Store
+
+// Store persists player profiles and the results of finished matches
+type Store interface {
+	Profile(profileId string) (Profile, error)
+	ProfileByToken(token string) (Profile, error)
+	ProfileByUsername(username string) (Profile, error)
+	SaveProfile(profile Profile) error
+	Update(profileId string, update func(profile *Profile)) (Profile, error)
+	AddMatch(match MatchRecord) error
+	Matches(profileId string, limit int) ([]MatchRecord, error)
+}
+
+// FileStore is a Store which keeps everything in memory. The profiles are written to a JSON file on every change,
+// the matches are appended to a log next to it.
+type FileStore struct {
+	path     string
+	matchLog string
+	mut      sync.RWMutex
+	data     fileStoreData
+	matches  []MatchRecord
+}
+
+// fileStoreData is the content of the FileStore file
+type fileStoreData struct {
+	Profiles map[string]Profile
+	Matches  []MatchRecord `json:",omitempty"` // Matches of files written before the match log, they are moved to the log when the store is opened
+}
+
+// NewFileStore opens the file store at the path, the files are created on the first change if they don't exist
+func NewFileStore(path string) (*FileStore, error) {
+	store := FileStore{
+		path:     path,
+		matchLog: strings.TrimSuffix(path, filepath.Ext(path)) + ".matches.jsonl",
+		data:     fileStoreData{Profiles: make(map[string]Profile)},
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &store.data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if store.data.Profiles == nil {
+		store.data.Profiles = make(map[string]Profile)
+	}
+
+	err = store.readMatchLog()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(store.data.Matches) != 0 {
+		oldMatches := store.data.Matches
+		store.data.Matches = nil
+		store.matches = append(oldMatches, store.matches...)
+		for _, match := range oldMatches {
+			err = store.appendMatch(match)
+			if err != nil {
+				return nil, err
+			}
+		}
+		err = store.save()
+	}
+
+	return &store, err
+}
+
+// Profile returns the profile with the id
+func (store *FileStore) Profile(profileId string) (Profile, error) {
+	store.mut.RLock()
+	defer store.mut.RUnlock()
+
+	profile, ok := store.data.Profiles[profileId]
+	if !ok {
+		return profile, ErrNotFound
+	}
+
+	return profile, nil
+}
+
+// ProfileByToken returns the profile the account token belongs to
+func (store *FileStore) ProfileByToken(token string) (Profile, error) {
+	store.mut.RLock()
+	defer store.mut.RUnlock()
+
+	for _, profile := range store.data.Profiles {
+		if profile.Token == token {
+			return profile, nil
+		}
+	}
+
+	return Profile{}, ErrNotFound
+}
+
+// ProfileByUsername returns the most recently active profile with the username, usernames are compared case insensitively
+func (store *FileStore) ProfileByUsername(username string) (Profile, error) {
+	store.mut.RLock()
+	defer store.mut.RUnlock()
+
+	var found *Profile
+	for _, profile := range store.data.Profiles {
+		profile := profile
+		if strings.EqualFold(profile.Username, username) && (found == nil || profile.LastSeen > found.LastSeen) {
+			found = &profile
+		}
+	}
+	if found == nil {
+		return Profile{}, ErrNotFound
+	}
+
+	return *found, nil
+}
+
+// SaveProfile creates or updates a profile
+func (store *FileStore) SaveProfile(profile Profile) error {
+	store.mut.Lock()
+	defer store.mut.Unlock()
+
+	store.data.Profiles[profile.ProfileId] = profile
+
+	return store.save()
+}
+
+// Update changes the profile with the id, the profile is read, changed and saved under the lock of the store so concurrent updates aren't lost
+func (store *FileStore) Update(profileId string, update func(profile *Profile)) (Profile, error) {
+	store.mut.Lock()
+	defer store.mut.Unlock()
+
+	profile, ok := store.data.Profiles[profileId]
+	if !ok {
+		return profile, ErrNotFound
+	}
+	update(&profile)
+	store.data.Profiles[profileId] = profile
+
+	return profile, store.save()
+}
+
+// AddMatch adds a finished match to the history
+func (store *FileStore) AddMatch(match MatchRecord) error {
+	store.mut.Lock()
+	defer store.mut.Unlock()
+
+	store.matches = append(store.matches, match)
+
+	return store.appendMatch(match)
+}
+
+// Matches returns the latest matches the profile has played in, newest first
+func (store *FileStore) Matches(profileId string, limit int) ([]MatchRecord, error) {
+	store.mut.RLock()
+	defer store.mut.RUnlock()
+
+	matches := []MatchRecord{}
+	for i := len(store.matches) - 1; i >= 0 && len(matches) < limit; i-- {
+		match := store.matches[i]
+		for _, player := range match.Players {
+			if player.ProfileId == profileId {
+				matches = append(matches, match)
+				break
+			}
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Date > matches[j].Date })
+
+	return matches, nil
+}
+
+// save writes the data to a temporary file and then replaces the store file with it, so a crash can't leave a half written file
+func (store *FileStore) save() error {
+	data, err := json.Marshal(store.data)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(store.path+".tmp", data, 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(store.path+".tmp", store.path)
+}
+
+// appendMatch writes a match to the end of the match log
+func (store *FileStore) appendMatch(match MatchRecord) error {
+	data, err := json.Marshal(match)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(store.matchLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(data, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// readMatchLog reads the matches of the match log, a match which was only partly written when the server stopped is skipped
+func (store *FileStore) readMatchLog() error {
+	file, err := os.Open(store.matchLog)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	for {
+		var match MatchRecord
+		err = decoder.Decode(&match)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+			return err
+		}
+		store.matches = append(store.matches, match)
+	}
+}
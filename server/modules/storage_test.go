@@ -0,0 +1,85 @@
+package modules
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestFileStoreUpdate(t *testing.T) {
+	path := t.TempDir() + "/data.json"
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.SaveProfile(Profile{ProfileId: "a", Token: "token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every update reads the profile the one before it has saved
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := store.Update("a", func(profile *Profile) { profile.Stats.Games++ })
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	reopened, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile, err := reopened.Profile("a")
+	if err != nil || profile.Stats.Games != 20 {
+		t.Errorf("profile has %d games after 20 updates, %v", profile.Stats.Games, err)
+	}
+
+	if _, err = store.Update("missing", func(profile *Profile) {}); err != ErrNotFound {
+		t.Errorf("update of a missing profile returned %v, want ErrNotFound", err)
+	}
+}
+
+func TestFileStoreMatchLog(t *testing.T) {
+	dir := t.TempDir()
+	old := MatchRecord{MatchId: "old", Date: "1", Players: []MatchPlayer{{ProfileId: "a"}}}
+	// stores written before the match log kept the matches in the data file
+	data, err := json.Marshal(fileStoreData{Profiles: map[string]Profile{}, Matches: []MatchRecord{old}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(dir+"/data.json", data, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewFileStore(dir + "/data.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.AddMatch(MatchRecord{MatchId: "new", Date: "2", Players: []MatchPlayer{{ProfileId: "a"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFileStore(dir + "/data.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches, err := reopened.Matches("a", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 2 || matches[0].MatchId != "new" || matches[1].MatchId != "old" {
+		t.Errorf("matches = %+v, want the new and the old match", matches)
+	}
+	if len(reopened.data.Matches) != 0 {
+		t.Errorf("data file still has %d matches", len(reopened.data.Matches))
+	}
+}
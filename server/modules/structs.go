@@ -2,28 +2,10 @@ package modules
 
 import (
 	"sync"
-	"time"
 
 	"github.com/gorilla/websocket"
 )
 
-type Data struct {
-	Type       string
-	UserId     string
-	Username   string
-	Color      string
-	GameId     string
-	ReadyState bool
-	Action     string
-	Message    string
-	Date       string
-	Bomb       Bomb
-	PowerUp    []Powerup
-	GameInfo   Game
-	Users      []User
-	Move       string
-	Position   Position
-}
 type Position struct {
 	X int
 	Y int
@@ -37,19 +19,51 @@ type User struct {
 	ReadyState bool
 	Powerups   PlayerPowerUps
 	// Conn       *websocket.Conn
-	Conn     *Connection
+	Conn     *Connection `json:"-"`
 	Position Position
 	Lives    int
-	Invincibility time.Time
+	Invincibility int    // Game tick until which the user can't lose lives
+	SessionToken  string `json:"-"`
+	Spectator     bool   // Watches the game instead of playing, eliminated players become spectators
+	ProfileId     string
+	AccountToken  string        `json:"-"`
+	Stats         MatchStats    // Stats of the current game
+	Curse         Curse         // Skull curse of the player, Type is empty when the player isn't cursed
+	CurseImmunity int           // Game tick until which the user can't be cursed by touching a cursed player
+	Collected     []PowerupName `json:"-"` // Powerups picked up in the current game, they are dropped when the player is eliminated
+	Effects       []TimedEffect `json:"-"` // Powerup effects which are undone when they expire
+	Team          int           // Team of the player in team mode, 0 without teams
 }
 
 type Bomb struct {
 	Position      Position
 	UserId        UserId
+	Type          BombType
 	ExplosionArea [][]Position
+	DetonateTick  int             // Zero for remote bombs until they are detonated
+	Direction     Position        // Direction the bomb is sliding to after a kick, zero when it's not moving
+	LandTick      int             // Game tick when a thrown bomb lands, it can't explode or block players before it
+	passable      map[UserId]bool // players standing on the tile when the bomb was placed can walk off it
+	nextSlideTick int
+}
+
+// ActiveExplosion is a bomb blast which is still active on the grid
+type ActiveExplosion struct {
+	Bomb      Bomb
+	StartTick int
 }
 
 type Connection struct {
-	Conn *websocket.Conn
-	mut  sync.Mutex
+	Conn      *websocket.Conn
+	Codec     Codec
+	mut       sync.Mutex
+	outbound  chan outboundMessage // messages waiting for the writer of the websocket, nil once the writer is stopped
+	ackedTick int
+	closed    bool // the websocket was closed and the user is waiting to resume the session
+}
+
+// outboundMessage is an encoded message waiting to be written to a websocket
+type outboundMessage struct {
+	frameType int
+	data      []byte
 }
@@ -0,0 +1,143 @@
+package modules
+
+import (
+	"fmt"
+	"sort"
+)
+
+const teamCount = 2 // Teams in team mode
+
+// teamColors are the colors of the players of each team, they replace the random player colors in team mode
+var teamColors = map[int]string{
+	1: "#D72C41",
+	2: "#4284EF",
+}
+
+// PlayerColor returns the team color of the user in team mode, otherwise a random color no one else in the game has
+func (game *Game) PlayerColor(user *User) string {
+	if user.Team != 0 {
+		return teamColors[user.Team]
+	}
+	return RandColor(string(game.GameId))
+}
+
+// TeamSize returns how many players fit in a team, the player capacity is split between the teams
+func (game *Game) TeamSize() int {
+	return (game.Config.MaxPlayers() + teamCount - 1) / teamCount
+}
+
+// TeamSizes returns how many players are in each team, without the user
+func (game *Game) TeamSizes(userId UserId) map[int]int {
+	sizes := make(map[int]int)
+	for _, player := range GlobalGames.ListGamePlayers(game.GameId) {
+		if player.UserId != userId && player.Team != 0 {
+			sizes[player.Team]++
+		}
+	}
+	return sizes
+}
+
+// FreeTeam returns the team the user joins in team mode, the preferred team is kept while it has room, otherwise the smallest team is picked
+func (game *Game) FreeTeam(user *User, preferred int) int {
+	if !game.Config.TeamMode {
+		return 0
+	}
+
+	sizes := game.TeamSizes(user.UserId)
+	if preferred >= 1 && preferred <= teamCount && sizes[preferred] < game.TeamSize() {
+		return preferred
+	}
+
+	team := 1
+	for candidate := 2; candidate <= teamCount; candidate++ {
+		if sizes[candidate] < sizes[team] {
+			team = candidate
+		}
+	}
+	return team
+}
+
+// AssignTeams puts every player of the lobby into a team when team mode is turned on, and takes them out of their teams when it's turned off.
+// The players get the color of their team, or a random color again.
+func (game *Game) AssignTeams() {
+	players := GlobalGames.ListGamePlayers(game.GameId)
+	// players who joined first keep their team
+	sort.Slice(players, func(i, j int) bool { return players[i].Time < players[j].Time })
+
+	for _, player := range players {
+		user := GlobalClients.GetUser(player.UserId)
+		user.Team = 0
+		user.Color = ""
+	}
+	for _, player := range players {
+		user := GlobalClients.GetUser(player.UserId)
+		user.Team = game.FreeTeam(user, player.Team)
+		user.Color = game.PlayerColor(user)
+	}
+}
+
+// SwitchTeam moves the user to another team in the lobby, everyone in the lobby is told about the change
+func SwitchTeam(user *User, msg SwitchTeamInput) {
+	game := GlobalGames.GetGame(GameId(user.GameId))
+	if game.GameId == "global" || game.Status != InLobby || !game.Config.TeamMode {
+		sendLobbyError(user, "Teams can only be changed in a team mode lobby!")
+		return
+	}
+	if game.TeamSizes(user.UserId)[msg.Team] >= game.TeamSize() {
+		sendLobbyError(user, fmt.Sprintf("Team %d is full!", msg.Team))
+		return
+	}
+
+	user.Team = msg.Team
+	user.Color = game.PlayerColor(user)
+	user.ReadyState = false
+
+	err := GlobalGames.BroadcastToGame(game.GameId, TeamSwitched{
+		LobbyState: game.LobbyState(),
+		UserId:     user.UserId,
+		Team:       user.Team,
+		Message:    fmt.Sprintf("%s joined team %d", user.Username, user.Team),
+		Date:       CurrentTime(),
+	})
+	HandleError(err)
+}
+
+// Teams returns the teams which have players in the game
+func (game *Game) Teams() map[int]bool {
+	teams := make(map[int]bool)
+	for _, player := range GlobalGames.ListGamePlayers(game.GameId) {
+		teams[player.Team] = true
+	}
+	return teams
+}
+
+// AliveTeams returns the teams which have players left alive, every player is their own team outside team mode
+func (game *Game) AliveTeams() map[string]bool {
+	teams := make(map[string]bool)
+	for _, player := range game.AlivePlayers() {
+		teams[player.TeamKey()] = true
+	}
+	return teams
+}
+
+// TeamKey returns the key of the team of the user, users without a team are a team of their own
+func (user User) TeamKey() string {
+	if user.Team == 0 {
+		return string(user.UserId)
+	}
+	return fmt.Sprint(user.Team)
+}
+
+// CanHurt returns a boolean indicating whether an explosion of the killer can take lives from the user.
+// Teammates can't hurt each other unless friendly fire is on, players always hurt themselves.
+func (game *Game) CanHurt(killerId UserId, user *User) bool {
+	if !game.Config.TeamMode || game.Config.FriendlyFire || killerId == "" || killerId == user.UserId {
+		return true
+	}
+
+	killer := game.Participant(killerId)
+	if killer == nil {
+		return true
+	}
+	return killer.Team != user.Team
+}
@@ -0,0 +1,101 @@
+package modules
+
+import "testing"
+
+// newTeamGame begins a game with players 0 and 1 in team 1 and player 2 in team 2, or every player on their own outside team mode
+func newTeamGame(t *testing.T, teamMode bool, friendlyFire bool) (*Game, []*User) {
+	config := NewGameConfig()
+	config.TeamMode = teamMode
+	config.FriendlyFire = friendlyFire
+	game, users := newTestLobby(t, config, 3)
+	if teamMode {
+		for i, team := range []int{1, 1, 2} {
+			users[i].Team = team
+		}
+	}
+	game.BeginRound()
+	return game, users
+}
+
+func TestCanHurt(t *testing.T) {
+	tests := []struct {
+		name         string
+		teamMode     bool
+		friendlyFire bool
+		killer       int // index of the bomb owner, -1 for explosions without an owner
+		left         bool
+		want         bool
+	}{
+		{"free for all", false, false, 1, false, true},
+		{"teammate", true, false, 1, false, false},
+		{"teammate with friendly fire", true, true, 1, false, true},
+		{"opponent", true, false, 2, false, true},
+		{"own bomb", true, false, 0, false, true},
+		{"no owner", true, false, -1, false, true},
+		{"teammate who left", true, false, 1, true, false},
+		{"opponent who left", true, false, 2, true, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			game, users := newTeamGame(t, test.teamMode, test.friendlyFire)
+
+			var killerId UserId
+			if test.killer >= 0 {
+				killerId = users[test.killer].UserId
+			}
+			if test.left {
+				LeaveLobby(users[test.killer])
+				JoinLobby(users[test.killer], "global")
+			}
+
+			if got := game.CanHurt(killerId, users[0]); got != test.want {
+				t.Errorf("can hurt = %t, want %t", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTeamWinner(t *testing.T) {
+	tests := []struct {
+		name     string
+		teamMode bool
+		lives    [3]int
+		alive    int // teams with players left alive
+		winners  []int
+	}{
+		{"free for all, everyone alive", false, [3]int{3, 3, 3}, 3, []int{0, 1, 2}},
+		{"free for all, last player standing", false, [3]int{0, 1, 0}, 1, []int{1}},
+		{"both teams alive", true, [3]int{3, 0, 3}, 2, []int{0, 1, 2}},
+		{"last team standing", true, [3]int{3, 3, 0}, 1, []int{0, 1}},
+		{"eliminated teammate wins with the team", true, [3]int{0, 1, 0}, 1, []int{0, 1}},
+		{"last single player team standing", true, [3]int{0, 0, 1}, 1, []int{2}},
+		{"everyone eliminated", true, [3]int{0, 0, 0}, 0, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			game, users := newTeamGame(t, test.teamMode, false)
+			for i, lives := range test.lives {
+				users[i].Lives = lives
+			}
+
+			if got := len(game.AliveTeams()); got != test.alive {
+				t.Errorf("%d teams alive, want %d", got, test.alive)
+			}
+
+			winners := make(map[UserId]bool)
+			for _, winner := range game.GetWinner() {
+				winners[winner.UserId] = true
+			}
+			if len(winners) != len(test.winners) {
+				t.Errorf("%d winners, want %d", len(winners), len(test.winners))
+			}
+			for _, i := range test.winners {
+				if !winners[users[i].UserId] {
+					t.Errorf("player %d didn't win", i)
+				}
+			}
+		})
+	}
+}
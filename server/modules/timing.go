@@ -0,0 +1,78 @@
+package modules
+
+import (
+	"time"
+)
+
+// TimingProfile contains the durations of a match and when its grid shrinks
+type TimingProfile struct {
+	Name                string
+	MatchDuration       time.Duration
+	ShrinkStart         time.Duration // Time from the start of the match until the outer circles start shrinking
+	OuterShrinkDuration time.Duration // How long shrinking the 2 outer circles takes
+	InnerShrinkStep     time.Duration // Time between the inner tiles shrinking, the last one shrinks when the match ends
+	SuddenDeath         SuddenDeath
+}
+
+// SuddenDeath changes the rules towards the end of a match to force a winner
+type SuddenDeath struct {
+	Start    time.Duration // Time from the start of the match until sudden death, 0 for no sudden death
+	OneLife  bool          // Every player is left with their last life
+	MaxFlame bool          // Every bomb explodes across the whole grid
+}
+
+// TimingProfiles are the timing profiles the lobby host can choose from
+var TimingProfiles = map[string]TimingProfile{
+	"blitz": {
+		Name:                "blitz",
+		MatchDuration:       90 * time.Second,
+		ShrinkStart:         30 * time.Second,
+		OuterShrinkDuration: 20 * time.Second,
+		InnerShrinkStep:     30 * time.Millisecond,
+		SuddenDeath:         SuddenDeath{Start: 60 * time.Second, OneLife: true},
+	},
+	"classic": {
+		Name:                "classic",
+		MatchDuration:       3 * time.Minute,
+		ShrinkStart:         90 * time.Second,
+		OuterShrinkDuration: 30 * time.Second,
+		InnerShrinkStep:     50 * time.Millisecond,
+	},
+	"endurance": {
+		Name:                "endurance",
+		MatchDuration:       6 * time.Minute,
+		ShrinkStart:         3 * time.Minute,
+		OuterShrinkDuration: time.Minute,
+		InnerShrinkStep:     100 * time.Millisecond,
+		SuddenDeath:         SuddenDeath{Start: 5 * time.Minute, OneLife: true, MaxFlame: true},
+	},
+}
+
+// UpdateSuddenDeath starts sudden death on its tick, the players lose all but one life
+func (game *Game) UpdateSuddenDeath() {
+	suddenDeath := game.Config.Timing.SuddenDeath
+	if suddenDeath.Start == 0 || game.Tick != game.Ticks(suddenDeath.Start) {
+		return
+	}
+	game.suddenDeath = true
+
+	if suddenDeath.OneLife {
+		for _, player := range GlobalGames.ListGamePlayers(game.GameId) {
+			if player.Lives > 1 {
+				GlobalClients.GetUser(player.UserId).Lives = 1
+			}
+		}
+	}
+
+	game.Emit(SuddenDeathEvent{
+		OneLife:  suddenDeath.OneLife,
+		MaxFlame: suddenDeath.MaxFlame,
+		Message:  "Sudden death!",
+		Date:     CurrentTime(),
+	})
+}
+
+// MaxFlame returns a boolean indicating whether bombs explode across the whole grid because of sudden death
+func (game *Game) MaxFlame() bool {
+	return game.suddenDeath && game.Config.Timing.SuddenDeath.MaxFlame
+}
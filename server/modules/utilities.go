@@ -27,19 +27,26 @@ func RandCode() string {
 	return string(code)
 }
 
-// RandColor picks one color, blue, red, purple or green and returns it
+// playerColors are the colors players get, one for each player of a full lobby
+var playerColors = []string{"#D72C41", "#A864CC", "#70C36D", "#4284EF", "#E8C547", "#EE8434", "#3CC2C9", "#E86AAE"}
+
+// RandColor picks one of the player colors no one else in the game has and returns it, any color is picked once they are all taken
 func RandColor(gameId string) string {
-	var colors = []string{"#D72C41", "#A864CC", "#70C36D", "#4284EF"}
+	var colors = append([]string{}, playerColors...)
 
 	for _, user := range GlobalGames.ListGamePlayers(GameId(gameId)) {
 		for j, color := range colors {
 
 			if user.Color == color {
 				colors = append(colors[:j], colors[j+1:]...)
+				break
 			}
 
 		}
 	}
+	if len(colors) == 0 {
+		colors = playerColors
+	}
 
 	rand.Seed(time.Now().Unix()) // initialize global pseudo random generator
 	randColor := colors[rand.Intn(len(colors))]
@@ -53,9 +60,13 @@ func CurrentTime() string {
 }
 
 
-// Send Sends data to certain connection
-func (conn *Connection) Send(data Data) error {
-	conn.mut.Lock()
-	defer conn.mut.Unlock()
-	return conn.Conn.WriteJSON(data)
-}
\ No newline at end of file
+// containsPosition returns a boolean indicating whether the position is in the list
+func containsPosition(list []Position, pos Position) bool {
+	for _, item := range list {
+		if item == pos {
+			return true
+		}
+	}
+
+	return false
+}
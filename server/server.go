@@ -6,6 +6,8 @@ import (
 	ws "bomberman_dom/server/websocket"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -18,6 +20,37 @@ func main() {
 		logger.Error(err)
 	}
 
+	// Open the storage of player profiles and match history
+	dataFile := os.Getenv("DATA_FILE")
+	if dataFile == "" {
+		dataFile = "data.json"
+	}
+	mod.Storage, err = mod.NewFileStore(dataFile)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	// Powerups are defined in a JSON file, the built-in definitions are used without one
+	if powerupsFile := os.Getenv("POWERUPS_FILE"); powerupsFile != "" {
+		err = mod.LoadPowerups(powerupsFile)
+		if err != nil {
+			logger.Fatal(err)
+		}
+	}
+
+	// How many players are in a full QuickPlay match
+	if size := os.Getenv("MATCH_SIZE"); size != "" {
+		players, err := strconv.Atoi(size)
+		if err == nil {
+			err = mod.SetMatchSize(players)
+		}
+		if err != nil {
+			logger.Error(err)
+		}
+	}
+
+	go mod.RunMatchmaking()
+
 	// Handle routes
 	http.HandleFunc("/websocket", ws.WsEndpoint)
 
@@ -27,6 +60,20 @@ func main() {
 	game := mod.NewGame(gameConfig)
 	mod.GlobalGames.Add(&game)
 
+	// How long disconnected players can resume their session
+	if grace := os.Getenv("SESSION_GRACE_SECONDS"); grace != "" {
+		seconds, err := strconv.Atoi(grace)
+		if err != nil {
+			logger.Error(err)
+		} else {
+			mod.SessionGracePeriod = time.Duration(seconds) * time.Second
+		}
+	}
+
+	if replayDir := os.Getenv("REPLAY_DIR"); replayDir != "" {
+		mod.ReplayDir = replayDir
+	}
+
 	// Port
 	port := os.Getenv("VITE_BACKEND_PORT")
 	if len(port) < 2 {
@@ -0,0 +1,132 @@
+package websocket
+
+import (
+	mod "bomberman_dom/server/modules"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// handler decodes the payload of a message and handles it for the user who sent it
+type handler func(user *mod.User, payload json.RawMessage) error
+
+// handlers maps message types to their handlers
+var handlers = map[string]handler{}
+
+// on registers the handler of a message type, the payload is decoded into T and validated before the handler is called
+func on[T any](messageType string, handle func(user *mod.User, msg T)) {
+	handlers[messageType] = func(user *mod.User, payload json.RawMessage) error {
+		var msg T
+		if len(payload) != 0 {
+			err := json.Unmarshal(payload, &msg)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := mod.Validate(msg)
+		if err != nil {
+			return err
+		}
+
+		handle(user, msg)
+		return nil
+	}
+}
+
+// playerOnly lists the message types spectators can't send
+var playerOnly = map[string]bool{
+	"move":       true,
+	"bombPlaced": true,
+	"detonate":   true,
+	"kickBomb":   true,
+	"throwBomb":  true,
+}
+
+// locksItself lists the message types whose handlers lock the games they change themselves.
+// They move the user to another game, or save to disk before locking the game of the user.
+var locksItself = map[string]bool{
+	"authenticate": true,
+	"joinLobby":    true,
+	"createLobby":  true,
+	"quickPlay":    true,
+	"leaveGame":    true,
+	"leaveLobby":   true,
+}
+
+// dispatch calls the handler registered for the type of the envelope.
+// The other handlers run with the game of the user locked, as they change the game and users the game loop runs on.
+func dispatch(user *mod.User, envelope mod.RawEnvelope) error {
+	handle, ok := handlers[envelope.Type]
+	if !ok {
+		return fmt.Errorf("unknown message type '%s'", envelope.Type)
+	}
+	if locksItself[envelope.Type] {
+		return handle(user, envelope.Payload)
+	}
+
+	_, unlock := mod.LockUser(user)
+	defer unlock()
+	if user.Spectator && playerOnly[envelope.Type] {
+		return fmt.Errorf("spectators can't send '%s' messages", envelope.Type)
+	}
+
+	return handle(user, envelope.Payload)
+}
+
+func init() {
+	/* ======================== CHATS ========================*/
+	on("authenticate", mod.Authenticate)
+	on("sendMessage", mod.SendMessage)
+	on("profile", mod.LookupProfile)
+
+	/* ======================== LOBBIES ========================*/
+	on("joinLobby", func(user *mod.User, msg mod.JoinLobbyInput) {
+		mod.SwitchLobby(user, mod.GameId(strings.ToLower(msg.GameId)))
+	})
+	on("createLobby", func(user *mod.User, msg mod.CreateLobbyInput) {
+		mod.CreateLobby(user)
+	})
+	on("quickPlay", func(user *mod.User, msg mod.QuickPlayInput) {
+		mod.QuickPlay(user)
+	})
+	on("leaveQueue", func(user *mod.User, msg mod.LeaveQueueInput) {
+		mod.LeaveQueue(user)
+	})
+	on("userToggleReady", func(user *mod.User, msg mod.ToggleReadyInput) {
+		mod.ToggleUserReady(user)
+		mod.ReadyToPlay(user)
+	})
+	on("updateLobbySettings", mod.UpdateLobbySettings)
+	on("switchTeam", mod.SwitchTeam)
+	on("startGame", func(user *mod.User, msg mod.StartGameInput) {
+		mod.StartGame(user)
+	})
+	leaveLobby := func(user *mod.User, msg mod.LeaveLobbyInput) {
+		mod.SwitchLobby(user, "global")
+	}
+	on("leaveGame", leaveLobby)
+	on("leaveLobby", leaveLobby)
+
+	/* ======================== IN GAME ========================*/
+	on("move", func(user *mod.User, msg mod.MoveInput) {
+		mod.QueueInput(user, msg)
+	})
+	on("bombPlaced", func(user *mod.User, msg mod.BombPlacedInput) {
+		mod.QueueInput(user, msg)
+	})
+	on("detonate", func(user *mod.User, msg mod.DetonateInput) {
+		mod.QueueInput(user, msg)
+	})
+	on("kickBomb", func(user *mod.User, msg mod.KickBombInput) {
+		mod.QueueInput(user, msg)
+	})
+	on("throwBomb", func(user *mod.User, msg mod.ThrowBombInput) {
+		mod.QueueInput(user, msg)
+	})
+	on("ack", mod.AcknowledgeState)
+
+	/* ======================== REPLAYS ========================*/
+	on("listReplays", mod.ListReplays)
+	on("watchReplay", mod.WatchReplay)
+}
@@ -3,6 +3,8 @@ package websocket
 import (
 	"bomberman_dom/server/logger"
 	mod "bomberman_dom/server/modules"
+	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/google/uuid"
@@ -12,6 +14,7 @@ import (
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
+	Subprotocols:    mod.Subprotocols,
 }
 
 // WsEndpoint creates a WS connection from a HTTP request
@@ -21,101 +24,140 @@ func WsEndpoint(w http.ResponseWriter, r *http.Request) {
 	wsconn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		mod.HandleError(err)
+		return
 	}
 
 	logger.Log("New user connected")
-	conn := mod.Connection{
-		Conn: wsconn,
+	// outgoing messages are encoded with the subprotocol the client asked for, incoming messages are always JSON
+	conn := mod.NewConnection(wsconn, mod.CodecFor(wsconn.Subprotocol()))
+
+	hello, ok := Handshake(conn)
+	if !ok {
+		conn.Close(websocket.CloseProtocolError, "handshake failed")
+		return
+	}
+
+	// a client with a session token reattaches to the user of its dropped connection
+	if resumed := mod.Resume(hello.SessionToken, conn); resumed != nil {
+		logger.Log(resumed.Username + " resumed their session")
+		_, unlock := mod.LockUser(resumed)
+		err = resumed.Conn.Send(mod.Welcome{
+			ProtocolVersion: mod.ProtocolVersion,
+			UserId:          resumed.UserId,
+			Resumed:         true,
+		})
+		mod.HandleError(err)
+		mod.Resync(resumed)
+		unlock()
+
+		WsReader(resumed.Conn, wsconn, resumed.UserId)
+		return
 	}
 
 	user := mod.User{
-		Conn:   &conn,
+		Conn:   conn,
 		UserId: mod.UserId(uuid.NewString()),
 		GameId: "global",
 	}
 
 	mod.GlobalClients.Add(&user)
-	data := mod.Data{
-		UserId: string(user.UserId),
-		GameId: user.GameId,
+	unlock := mod.LockGames("global")
+	err = conn.Send(mod.Welcome{
+		ProtocolVersion: mod.ProtocolVersion,
+		UserId:          user.UserId,
+	})
+	mod.HandleError(err)
+	mod.JoinLobby(&user, "global")
+	unlock()
+
+	WsReader(conn, wsconn, user.UserId)
+}
+
+// Handshake waits for the hello message of the client and checks that it uses the same protocol version as the server
+func Handshake(conn *mod.Connection) (hello mod.Hello, ok bool) {
+	var envelope mod.RawEnvelope
+
+	err := conn.Conn.ReadJSON(&envelope)
+	if err != nil {
+		return hello, false
+	}
+
+	if envelope.Version != mod.ProtocolVersion {
+		RejectProtocol(conn, fmt.Sprintf("Protocol version %d is not supported, this server uses version %d. Please update your client.", envelope.Version, mod.ProtocolVersion))
+		return hello, false
+	}
+	if envelope.Type != "hello" {
+		RejectProtocol(conn, fmt.Sprintf("Expected a 'hello' message to start the connection, got '%s'.", envelope.Type))
+		return hello, false
 	}
-	mod.JoinLobby(data)
 
-	WsReader(&conn, user.UserId)
+	if len(envelope.Payload) != 0 {
+		err = json.Unmarshal(envelope.Payload, &hello)
+		if err != nil {
+			RejectProtocol(conn, fmt.Sprintf("Invalid 'hello' message: %s", err.Error()))
+			return hello, false
+		}
+	}
+
+	return hello, true
 }
 
-// WebsocketClosed removes player when ws connection has been stopped
-func WebsocketClosed(uId mod.UserId, conn *mod.Connection) {
-	user := mod.GlobalClients.GetUser(uId)
+// RejectProtocol tells the client why it was rejected and closes the connection with a protocol error
+func RejectProtocol(conn *mod.Connection, message string) {
+	logger.Warning(message)
 
-	data := mod.Data{
-		UserId: string(uId),
-		GameId: user.GameId,
-	}
+	err := conn.Send(mod.ProtocolError{
+		ProtocolVersion: mod.ProtocolVersion,
+		Message:         message,
+	})
+	mod.HandleError(err)
+
+	conn.Close(websocket.CloseProtocolError, "unsupported protocol")
+}
 
-	mod.LeaveLobby(data)
-	// if current game was not global then remove player from global 'game' (lobby) as well
-	if user.GameId != "global" {
-		user.GameId = "global"
-		mod.LeaveLobby(data)
+// WebsocketClosed detaches the closed websocket from the user, who is kept for a grace period if they have a session
+func WebsocketClosed(uId mod.UserId, conn *mod.Connection, wsconn *websocket.Conn) {
+	// the user has already resumed their session on another websocket
+	if !conn.Detach(wsconn) {
+		return
 	}
 
-	mod.GlobalClients.Del(uId)
-	conn.Conn.Close()
+	user := mod.GlobalClients.GetUser(uId)
+	if user == nil {
+		return
+	}
+	// users without a session leave their game and the global chat right away
+	_, unlock := mod.LockUser(user, "global")
+	defer unlock()
+	mod.Disconnect(user)
 }
 
 // WsReader recieves all incoming data from client
-func WsReader(conn *mod.Connection, userId mod.UserId) {
+func WsReader(conn *mod.Connection, wsconn *websocket.Conn, userId mod.UserId) {
 	go func() {
 		for {
-			var data mod.Data
+			var envelope mod.RawEnvelope
 
-			err := conn.Conn.ReadJSON(&data)
-			data.UserId = string(userId)
+			err := wsconn.ReadJSON(&envelope)
 			if err != nil {
-				WebsocketClosed(userId, conn)
+				WebsocketClosed(userId, conn, wsconn)
+				return
+			}
+
+			if envelope.Version != mod.ProtocolVersion {
+				RejectProtocol(conn, fmt.Sprintf("Protocol version %d is not supported, this server uses version %d.", envelope.Version, mod.ProtocolVersion))
+				WebsocketClosed(userId, conn, wsconn)
 				return
 			}
 
-			switch data.Type {
-
-			/* ======================== CHATS ========================*/
-			case "authenticate":
-				mod.Authenticate(data)
-			case "sendMessage":
-				mod.SendMessage(data)
-
-			/* ======================== LOBBIES ========================*/
-			case "joinLobby":
-				if !(mod.LobbyExists(data, conn)) {
-					break
-				}
-				mod.LeaveLobby(data)
-				mod.JoinLobby(data)
-			case "createLobby":
-				mod.CreateLobby(data)
-			case "quickPlay":
-				mod.QuickPlay(data)
-			case "userToggleReady":
-				mod.ToggleUserReady(data)
-				mod.ReadyToPlay(data)
-			case "startGame":
-				mod.StartGame(data)
-			case "leaveGame":
-				fallthrough
-			case "leaveLobby":
-				mod.LeaveLobby(data)
-				data.GameId = "global"
-				mod.JoinLobby(data)
-
-			/* ======================== IN GAME ========================*/
-			case "move":
-				mod.MovePlayer(data)
-			case "bombPlaced":
-				mod.BombPlaced(data)
-
-			default:
-				logger.Error(err)
+			err = dispatch(mod.GlobalClients.GetUser(userId), envelope)
+			if err != nil {
+				logger.Warning(fmt.Sprintf("Rejected '%s' message: %s", envelope.Type, err.Error()))
+				err = conn.Send(mod.MessageError{
+					Rejected: envelope.Type,
+					Message:  err.Error(),
+				})
+				mod.HandleError(err)
 			}
 		}
 	}()